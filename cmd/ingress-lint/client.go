@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// manifestClient 用本地清单实现 capabilities.WapcClient，使策略可以在 wasm 运行时之外运行。
+// 行为与 validate_test.go 中的 mockWapcClient 一致：资源按 kind/namespace/name 索引。
+type manifestClient struct {
+	resources map[string]*manifest
+	// ordered 保存清单的读取顺序，用于 list 操作。
+	ordered []*manifest
+}
+
+// newManifestClient 以给定的清单建立索引，同名对象以后出现的为准。
+func newManifestClient(manifests []*manifest) *manifestClient {
+	c := &manifestClient{resources: make(map[string]*manifest, len(manifests))}
+	for _, m := range manifests {
+		key := resourceKey(m.Kind, m.Namespace, m.Name)
+		if _, ok := c.resources[key]; !ok {
+			c.ordered = append(c.ordered, m)
+		}
+		c.resources[key] = m
+	}
+	return c
+}
+
+// resourceKey 生成资源索引的键。
+func resourceKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// resourceRequest 为 kubernetes host capabilities 请求中用到的字段。
+type resourceRequest struct {
+	APIVersion    string `json:"api_version"`
+	Kind          string `json:"kind"`
+	Namespace     string `json:"namespace"`
+	Name          string `json:"name"`
+	LabelSelector string `json:"label_selector"`
+}
+
+// HostCall 实现 capabilities.WapcClient。
+func (c *manifestClient) HostCall(binding, namespace, operation string, payload []byte) ([]byte, error) {
+	if binding != "kubewarden" || namespace != "kubernetes" {
+		return nil, fmt.Errorf("host capability %s/%s/%s is not available offline", binding, namespace, operation)
+	}
+	req := resourceRequest{}
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return nil, err
+	}
+	switch operation {
+	case "get_resource":
+		return c.get(req)
+	case "list_resources_by_namespace":
+		return c.list(req, true)
+	case "list_resources_all":
+		return c.list(req, false)
+	}
+	return nil, fmt.Errorf("host capability %s/%s/%s is not available offline", binding, namespace, operation)
+}
+
+// get 返回单个资源。清单中引用到的命名空间视为存在，即使没有提供 Namespace 对象。
+func (c *manifestClient) get(req resourceRequest) ([]byte, error) {
+	if m, ok := c.resources[resourceKey(req.Kind, req.Namespace, req.Name)]; ok {
+		return m.Raw, nil
+	}
+	if req.Kind == "Namespace" {
+		return json.Marshal(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Namespace",
+			"metadata":   map[string]string{"name": req.Name},
+		})
+	}
+	// 与 kwctl 的错误信息保持一致，策略据此识别为资源不存在
+	return nil, fmt.Errorf("Cannot find %s/%s named '%s' inside of namespace 'Some(\"%s\")'",
+		req.APIVersion, req.Kind, req.Name, req.Namespace)
+}
+
+// list 返回匹配类型、命名空间与标签选择器的资源组成的 List 对象。
+func (c *manifestClient) list(req resourceRequest, namespaced bool) ([]byte, error) {
+	requirements, err := parseLabelSelector(req.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	items := []json.RawMessage{}
+	for _, m := range c.ordered {
+		m = c.resources[resourceKey(m.Kind, m.Namespace, m.Name)]
+		if m.Kind != req.Kind || (namespaced && m.Namespace != req.Namespace) {
+			continue
+		}
+		header := objectHeader{}
+		if err := json.Unmarshal(m.Raw, &header); err != nil {
+			return nil, err
+		}
+		if labelsMatch(header.Metadata.Labels, requirements) {
+			items = append(items, m.Raw)
+		}
+	}
+	return json.Marshal(map[string]interface{}{"items": items})
+}
+
+// labelRequirement 是基于等式的标签选择器中的一项。
+type labelRequirement struct {
+	key, value string
+	negated    bool
+}
+
+// parseLabelSelector 解析 key=value、key==value 与 key!=value 形式的标签选择器。
+func parseLabelSelector(selector string) ([]labelRequirement, error) {
+	var requirements []labelRequirement
+	for _, part := range strings.Split(selector, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if key, value, ok := strings.Cut(part, "!="); ok {
+			requirements = append(requirements, labelRequirement{key: key, value: value, negated: true})
+			continue
+		}
+		key, value, ok := strings.Cut(strings.Replace(part, "==", "=", 1), "=")
+		if !ok {
+			return nil, fmt.Errorf("unsupported label selector '%s'", selector)
+		}
+		requirements = append(requirements, labelRequirement{key: key, value: value})
+	}
+	return requirements, nil
+}
+
+// labelsMatch 判断标签是否满足全部选择器条件。
+func labelsMatch(labels map[string]string, requirements []labelRequirement) bool {
+	for _, req := range requirements {
+		if (labels[req.key] == req.value) == req.negated {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testClient(t *testing.T) *manifestClient {
+	t.Helper()
+	manifests, err := parseManifests("test.yaml", []byte(`apiVersion: discovery.k8s.io/v1
+kind: EndpointSlice
+metadata:
+  name: web-abc
+  namespace: shop
+  labels:
+    kubernetes.io/service-name: web
+---
+apiVersion: discovery.k8s.io/v1
+kind: EndpointSlice
+metadata:
+  name: api-abc
+  namespace: shop
+  labels:
+    kubernetes.io/service-name: api
+---
+apiVersion: discovery.k8s.io/v1
+kind: EndpointSlice
+metadata:
+  name: web-def
+  namespace: other
+  labels:
+    kubernetes.io/service-name: web
+`), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newManifestClient(manifests)
+}
+
+// listNames 调用 list 操作并返回结果中的对象名称。
+func listNames(t *testing.T, c *manifestClient, operation, request string) []string {
+	t.Helper()
+	response, err := c.HostCall("kubewarden", "kubernetes", operation, []byte(request))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	list := struct {
+		Items []objectHeader `json:"items"`
+	}{}
+	if err := json.Unmarshal(response, &list); err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		names = append(names, item.Metadata.Name)
+	}
+	return names
+}
+
+func TestManifestClientLists(t *testing.T) {
+	c := testClient(t)
+	names := listNames(t, c, "list_resources_by_namespace",
+		`{"kind":"EndpointSlice","namespace":"shop","label_selector":"kubernetes.io/service-name=web"}`)
+	if strings.Join(names, ",") != "web-abc" {
+		t.Errorf("Unexpected namespaced list result: %v", names)
+	}
+	names = listNames(t, c, "list_resources_all", `{"kind":"EndpointSlice","label_selector":"kubernetes.io/service-name!=api"}`)
+	if strings.Join(names, ",") != "web-abc,web-def" {
+		t.Errorf("Unexpected cluster-wide list result: %v", names)
+	}
+}
+
+func TestManifestClientGet(t *testing.T) {
+	c := testClient(t)
+	if _, err := c.HostCall("kubewarden", "kubernetes", "get_resource",
+		[]byte(`{"api_version":"v1","kind":"Service","namespace":"shop","name":"web"}`)); err == nil ||
+		!strings.Contains(err.Error(), "Cannot find v1/Service named 'web'") {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+	// 未提供的 Namespace 对象视为存在
+	response, err := c.HostCall("kubewarden", "kubernetes", "get_resource", []byte(`{"api_version":"v1","kind":"Namespace","name":"shop"}`))
+	if err != nil || !strings.Contains(string(response), `"name":"shop"`) {
+		t.Errorf("Expected a synthesized Namespace, got %s (%v)", response, err)
+	}
+	if _, err := c.HostCall("kubewarden", "crypto", "v1/is_certificate_trusted", []byte(`{}`)); err == nil {
+		t.Error("Expected crypto capabilities to be unavailable offline")
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+
+	"github.com/vvlisn/deny-ingress-no-service/internal/policy"
+)
+
+// 结果级别，与 SARIF 的 level 取值一致。
+const (
+	levelError   = "error"
+	levelWarning = "warning"
+)
+
+// lintableKinds 为会交给策略校验的资源类型及其 API 组。
+//
+//nolint:gochecknoglobals // 只读的查找表
+var lintableKinds = map[string]string{
+	"Ingress":        "networking.k8s.io",
+	"HTTPRoute":      "gateway.networking.k8s.io",
+	"GRPCRoute":      "gateway.networking.k8s.io",
+	"IngressRoute":   "traefik.io",
+	"VirtualService": "networking.istio.io",
+	"Route":          "route.openshift.io",
+}
+
+// finding 是一个对象的校验结果。
+type finding struct {
+	Path      string `json:"file"`
+	Line      int    `json:"line"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+}
+
+// lintResult 汇总一次运行的全部结果。
+type lintResult struct {
+	Checked  int        `json:"checked"`
+	Findings []*finding `json:"findings"`
+}
+
+// violations 返回级别为 error 的结果数量。
+func (r *lintResult) violations() int {
+	count := 0
+	for _, f := range r.Findings {
+		if f.Level == levelError {
+			count++
+		}
+	}
+	return count
+}
+
+// checkSettings 使用策略的 validate_settings 入口校验设置。
+func checkSettings(settings json.RawMessage) error {
+	responseBytes, err := policy.ValidateSettings(settings)
+	if err != nil {
+		return err
+	}
+	response := kubewarden_protocol.SettingsValidationResponse{}
+	if err := json.Unmarshal(responseBytes, &response); err != nil {
+		return err
+	}
+	if !response.Valid {
+		message := "invalid settings"
+		if response.Message != nil {
+			message = *response.Message
+		}
+		return errors.New(message)
+	}
+	return nil
+}
+
+// lint 将清单中的 Ingress 与 Gateway API 路由逐个交给策略校验，
+// 其余对象作为 host capabilities 的数据来源。
+func lint(manifests []*manifest, settings json.RawMessage) (*lintResult, error) {
+	policy.SetHostClient(newManifestClient(manifests))
+	result := &lintResult{Findings: []*finding{}}
+	for _, m := range manifests {
+		group, ok := lintableKinds[m.Kind]
+		if !ok || !strings.HasPrefix(m.APIVersion, group+"/") {
+			continue
+		}
+		result.Checked++
+		response, err := validateManifest(m, settings)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", m.Path, m.Line, err)
+		}
+		if response.Message == nil || *response.Message == "" {
+			continue
+		}
+		level := levelWarning
+		if !response.Accepted {
+			level = levelError
+		}
+		result.Findings = append(result.Findings, &finding{
+			Path:      m.Path,
+			Line:      m.Line,
+			Kind:      m.Kind,
+			Namespace: m.Namespace,
+			Name:      m.Name,
+			Level:     level,
+			Message:   *response.Message,
+		})
+	}
+	return result, nil
+}
+
+// validateManifest 构造 CREATE 请求并调用策略的 validate 入口。
+func validateManifest(m *manifest, settings json.RawMessage) (*kubewarden_protocol.ValidationResponse, error) {
+	group, version, _ := strings.Cut(m.APIVersion, "/")
+	payload, err := json.Marshal(kubewarden_protocol.ValidationRequest{
+		Request: kubewarden_protocol.KubernetesAdmissionRequest{
+			Uid:       fmt.Sprintf("%s:%d", m.Path, m.Line),
+			Kind:      kubewarden_protocol.GroupVersionKind{Group: group, Version: version, Kind: m.Kind},
+			Name:      m.Name,
+			Namespace: m.Namespace,
+			Operation: "CREATE",
+			Object:    m.Raw,
+		},
+		Settings: settings,
+	})
+	if err != nil {
+		return nil, err
+	}
+	responseBytes, err := policy.Validate(payload)
+	if err != nil {
+		return nil, err
+	}
+	response := &kubewarden_protocol.ValidationResponse{}
+	if err := json.Unmarshal(responseBytes, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,103 @@
+// ingress-lint 在集群之外运行 deny-ingress-no-service 策略：
+// 读取一组 YAML/JSON 清单，用其中的 Service、Secret 等对象回答策略的 host capabilities 查询，
+// 校验其中的 Ingress 与 Gateway API 路由。
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/vvlisn/deny-ingress-no-service/internal/policy"
+)
+
+// 退出码。
+const (
+	exitOK         = 0
+	exitViolations = 1
+	exitError      = 2
+)
+
+// defaultSettings 与策略的默认行为一致：校验 Service 是否存在。
+// 设置文件中省略的字段同样由策略按默认值补齐，不会关闭校验。
+const defaultSettings = `{"enforce_service_exists": true}`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run 执行一次检查并返回退出码。
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("ingress-lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("output", outputText, "output format: text, json or sarif")
+	settingsFile := flags.String("settings", "", "policy settings file (YAML or JSON)")
+	namespace := flags.String("namespace", "default", "namespace for objects that do not set one")
+	verbose := flags.Bool("verbose", false, "write policy logs to stderr")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: ingress-lint [flags] PATH...")
+		fmt.Fprintln(stderr, "Checks the Ingresses and Gateway API routes found in PATH (files or directories)")
+		fmt.Fprintln(stderr, "against the Services, Secrets and other objects found in the same manifests.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitError
+	}
+
+	// 非 wasm 环境下策略日志默认写到标准输出，会破坏 JSON/SARIF 输出
+	if *verbose {
+		policy.SetLogWriter(stderr)
+	} else {
+		policy.SetLogWriter(io.Discard)
+	}
+
+	settings, err := loadSettings(*settingsFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "ingress-lint: %s\n", err)
+		return exitError
+	}
+	manifests, err := loadManifests(flags.Args(), *namespace)
+	if err != nil {
+		fmt.Fprintf(stderr, "ingress-lint: %s\n", err)
+		return exitError
+	}
+	result, err := lint(manifests, settings)
+	if err != nil {
+		fmt.Fprintf(stderr, "ingress-lint: %s\n", err)
+		return exitError
+	}
+	if err := writeResult(stdout, *output, result); err != nil {
+		fmt.Fprintf(stderr, "ingress-lint: %s\n", err)
+		return exitError
+	}
+	if result.violations() > 0 {
+		return exitViolations
+	}
+	return exitOK
+}
+
+// loadSettings 读取并校验策略设置，未指定文件时使用 defaultSettings。
+func loadSettings(path string) (json.RawMessage, error) {
+	settings := json.RawMessage(defaultSettings)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if settings, err = yaml.YAMLToJSON(data); err != nil {
+			return nil, fmt.Errorf("cannot parse settings %s: %w", path, err)
+		}
+	}
+	if err := checkSettings(settings); err != nil {
+		return nil, fmt.Errorf("invalid settings: %w", err)
+	}
+	return settings, nil
+}
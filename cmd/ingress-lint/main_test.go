@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunReportsViolationsAsText(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"testdata"}, &stdout, &stderr)
+	if code != exitViolations {
+		t.Fatalf("Expected exit code %d, got %d (stderr: %s)", exitViolations, code, stderr.String())
+	}
+	output := stdout.String()
+	for _, expected := range []string{
+		filepath.Join("testdata", "ingresses.yaml") + ":20: error: Ingress shop/checkout: ",
+		"Service 'checkout' does not exist in namespace 'shop'",
+		"Service 'api' in namespace 'shop' does not expose port 8080",
+		"3 object(s) checked, 1 rejected",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s', got:\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "storefront") {
+		t.Errorf("Expected valid objects not to be reported, got:\n%s", output)
+	}
+}
+
+func TestRunWithoutViolations(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{filepath.Join("testdata", "services.yaml"), filepath.Join("testdata", "route.json")}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d (stdout: %s, stderr: %s)", exitOK, code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "1 object(s) checked, 0 rejected") {
+		t.Errorf("Unexpected output: %s", stdout.String())
+	}
+}
+
+func TestRunJSONOutput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-output", "json", "testdata"}, &stdout, &stderr); code != exitViolations {
+		t.Fatalf("Expected exit code %d, got %d", exitViolations, code)
+	}
+	result := lintResult{}
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("Invalid JSON output: %v\n%s", err, stdout.String())
+	}
+	if result.Checked != 3 || len(result.Findings) != 1 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+	f := result.Findings[0]
+	if f.Name != "checkout" || f.Namespace != "shop" || f.Level != levelError || f.Line != 20 {
+		t.Errorf("Unexpected finding: %+v", f)
+	}
+}
+
+func TestRunSARIFOutput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-output", "sarif", "testdata"}, &stdout, &stderr); code != exitViolations {
+		t.Fatalf("Expected exit code %d, got %d", exitViolations, code)
+	}
+	log := sarifLog{}
+	if err := json.Unmarshal(stdout.Bytes(), &log); err != nil {
+		t.Fatalf("Invalid SARIF output: %v", err)
+	}
+	if log.Version != sarifVersion || len(log.Runs) != 1 || len(log.Runs[0].Results) != 1 {
+		t.Fatalf("Unexpected SARIF log: %+v", log)
+	}
+	location := log.Runs[0].Results[0].Locations[0].PhysicalLocation
+	if location.ArtifactLocation.URI != "testdata/ingresses.yaml" || location.Region.StartLine != 20 {
+		t.Errorf("Unexpected SARIF location: %+v", location)
+	}
+}
+
+func TestRunWithSettingsFile(t *testing.T) {
+	dir := t.TempDir()
+	settingsPath := filepath.Join(dir, "settings.yaml")
+	if err := os.WriteFile(settingsPath, []byte("enforcement_mode: warn\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-settings", settingsPath, "testdata"}, &stdout, &stderr); code != exitOK {
+		t.Errorf("Expected warn mode to exit with %d, got %d: %s", exitOK, code, stdout.String())
+	}
+
+	if err := os.WriteFile(settingsPath, []byte("enforcement_mode: audit\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"-settings", settingsPath, "testdata"}, &stdout, &stderr); code != exitError {
+		t.Errorf("Expected invalid settings to exit with %d, got %d", exitError, code)
+	}
+	if !strings.Contains(stderr.String(), "invalid enforcement_mode 'audit'") {
+		t.Errorf("Unexpected error output: %s", stderr.String())
+	}
+}
+
+func TestRunWithPartialSettingsKeepsDefaults(t *testing.T) {
+	dir := t.TempDir()
+	settingsPath := filepath.Join(dir, "settings.yaml")
+	if err := os.WriteFile(settingsPath, []byte("validate_ingress_class: false\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-settings", settingsPath, "testdata"}, &stdout, &stderr); code != exitViolations {
+		t.Errorf("Expected settings without enforce_service_exists to keep checking, got exit %d: %s", code, stdout.String())
+	}
+	if !strings.Contains(stdout.String(), "3 object(s) checked, 1 rejected") {
+		t.Errorf("Unexpected output: %s", stdout.String())
+	}
+}
+
+func TestRunUsageErrors(t *testing.T) {
+	tests := [][]string{
+		{},
+		{"-output", "xml", "testdata"},
+		{filepath.Join("testdata", "missing.yaml")},
+	}
+	for _, args := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, &stdout, &stderr); code != exitError {
+			t.Errorf("Expected exit code %d for %v, got %d", exitError, args, code)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// clusterScopedKinds 为不带命名空间的资源类型，其余资源缺少命名空间时使用 --namespace。
+//
+//nolint:gochecknoglobals // 只读的查找表
+var clusterScopedKinds = map[string]bool{
+	"Namespace":    true,
+	"IngressClass": true,
+}
+
+// manifest 是从文件中读取的一个 Kubernetes 对象。
+type manifest struct {
+	// Path 为对象所在的文件。
+	Path string
+	// Line 为对象所在 YAML 文档的起始行，从 1 开始。
+	Line int
+	// APIVersion、Kind、Namespace、Name 为对象的标识。
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+	// Raw 为对象的 JSON 表示，已补全命名空间。
+	Raw json.RawMessage
+}
+
+// objectHeader 是解析对象标识所需的字段。
+type objectHeader struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Items []json.RawMessage `json:"items"`
+}
+
+// loadManifests 读取给定文件与目录（递归查找 .yaml、.yml、.json）中的全部对象。
+func loadManifests(paths []string, defaultNamespace string) ([]*manifest, error) {
+	files, err := collectFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	var manifests []*manifest
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		fileManifests, err := parseManifests(file, data, defaultNamespace)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, fileManifests...)
+	}
+	return manifests, nil
+}
+
+// collectFiles 展开目录并按路径排序，保证输出顺序稳定。
+func collectFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && isManifestFile(p) {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// isManifestFile 判断文件扩展名是否为 YAML 或 JSON。
+func isManifestFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// parseManifests 将文件内容拆分为 YAML 文档并解析其中的对象，List 对象会展开为其中的元素。
+// JSON 是 YAML 的子集，因此 JSON 文件走同样的流程。
+func parseManifests(path string, data []byte, defaultNamespace string) ([]*manifest, error) {
+	var manifests []*manifest
+	for _, doc := range splitDocuments(data) {
+		jsonData, err := yaml.YAMLToJSON(doc.data)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, doc.line, err)
+		}
+		if len(bytes.TrimSpace(jsonData)) == 0 || string(jsonData) == "null" {
+			continue
+		}
+		objects, err := expandList(jsonData)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, doc.line, err)
+		}
+		for _, object := range objects {
+			m, err := newManifest(path, doc.line, object, defaultNamespace)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, doc.line, err)
+			}
+			if m != nil {
+				manifests = append(manifests, m)
+			}
+		}
+	}
+	return manifests, nil
+}
+
+// document 是多文档 YAML 中的一个文档及其起始行。
+type document struct {
+	line int
+	data []byte
+}
+
+// splitDocuments 按 --- 分隔符拆分多文档 YAML。
+func splitDocuments(data []byte) []document {
+	var docs []document
+	current := document{line: 1}
+	lineNo := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if strings.HasPrefix(line, "---") && strings.TrimSpace(strings.TrimPrefix(line, "---")) == "" {
+			docs = append(docs, current)
+			current = document{line: lineNo + 1}
+			continue
+		}
+		current.data = append(current.data, line...)
+		current.data = append(current.data, '\n')
+	}
+	return append(docs, current)
+}
+
+// expandList 返回文档中的对象；kind 以 List 结尾的对象展开为其 items。
+func expandList(jsonData []byte) ([]json.RawMessage, error) {
+	header := objectHeader{}
+	if err := json.Unmarshal(jsonData, &header); err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(header.Kind, "List") && header.Items != nil {
+		return header.Items, nil
+	}
+	return []json.RawMessage{jsonData}, nil
+}
+
+// newManifest 解析对象标识，并为缺少命名空间的命名空间级对象补全命名空间。
+// 没有 kind 的文档不是 Kubernetes 对象，返回 nil。
+func newManifest(path string, line int, object json.RawMessage, defaultNamespace string) (*manifest, error) {
+	header := objectHeader{}
+	if err := json.Unmarshal(object, &header); err != nil {
+		return nil, err
+	}
+	if header.Kind == "" {
+		return nil, nil
+	}
+	m := &manifest{
+		Path:       path,
+		Line:       line,
+		APIVersion: header.APIVersion,
+		Kind:       header.Kind,
+		Namespace:  header.Metadata.Namespace,
+		Name:       header.Metadata.Name,
+		Raw:        object,
+	}
+	if clusterScopedKinds[m.Kind] {
+		m.Namespace = ""
+		return m, nil
+	}
+	if m.Namespace == "" {
+		m.Namespace = defaultNamespace
+		raw, err := setNamespace(object, defaultNamespace)
+		if err != nil {
+			return nil, err
+		}
+		m.Raw = raw
+	}
+	return m, nil
+}
+
+// setNamespace 返回设置了 metadata.namespace 的对象副本。
+func setNamespace(object json.RawMessage, namespace string) (json.RawMessage, error) {
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(object, &fields); err != nil {
+		return nil, err
+	}
+	metadata, _ := fields["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadata["namespace"] = namespace
+	fields["metadata"] = metadata
+	return json.Marshal(fields)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseManifests(t *testing.T) {
+	data := []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+# 只有注释的文档会被忽略
+---
+apiVersion: networking.k8s.io/v1
+kind: IngressClass
+metadata:
+  name: nginx
+  namespace: ignored
+---
+apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: Secret
+    metadata:
+      name: tls
+      namespace: shop
+`)
+	manifests, err := parseManifests("test.yaml", data, "default")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []struct {
+		kind, namespace, name string
+		line                  int
+	}{
+		{"Service", "default", "web", 1},
+		{"IngressClass", "", "nginx", 8},
+		{"Secret", "shop", "tls", 14},
+	}
+	if len(manifests) != len(expected) {
+		t.Fatalf("Expected %d manifests, got %d", len(expected), len(manifests))
+	}
+	for i, e := range expected {
+		m := manifests[i]
+		if m.Kind != e.kind || m.Namespace != e.namespace || m.Name != e.name || m.Line != e.line {
+			t.Errorf("manifest %d: expected %+v, got %s %s/%s at line %d", i, e, m.Kind, m.Namespace, m.Name, m.Line)
+		}
+	}
+
+	// 缺少命名空间的对象在 JSON 中也会补全命名空间
+	header := objectHeader{}
+	if err := json.Unmarshal(manifests[0].Raw, &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Metadata.Namespace != "default" {
+		t.Errorf("Expected namespace to be defaulted, got '%s'", header.Metadata.Namespace)
+	}
+}
+
+func TestParseManifestsInvalidYAML(t *testing.T) {
+	if _, err := parseManifests("broken.yaml", []byte("kind: [Service\n"), "default"); err == nil {
+		t.Error("Expected an error for invalid YAML")
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// 支持的输出格式。
+const (
+	outputText  = "text"
+	outputJSON  = "json"
+	outputSARIF = "sarif"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	toolName     = "ingress-lint"
+	toolURI      = "https://github.com/vvlisn/deny-ingress-no-service"
+	// ruleID 为所有结果共用的规则标识：策略的一次拒绝可能同时包含多类问题。
+	ruleID = "deny-ingress-no-service"
+)
+
+// writeResult 以指定格式输出结果。
+func writeResult(w io.Writer, format string, result *lintResult) error {
+	switch format {
+	case outputText:
+		return writeText(w, result)
+	case outputJSON:
+		return writeJSON(w, result)
+	case outputSARIF:
+		return writeSARIF(w, result)
+	}
+	return fmt.Errorf("unknown output format '%s': must be one of %s, %s, %s", format, outputText, outputJSON, outputSARIF)
+}
+
+// writeText 每个结果输出一行，最后输出汇总。
+func writeText(w io.Writer, result *lintResult) error {
+	for _, f := range result.Findings {
+		if _, err := fmt.Fprintf(w, "%s:%d: %s: %s %s/%s: %s\n",
+			f.Path, f.Line, f.Level, f.Kind, f.Namespace, f.Name, f.Message); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d object(s) checked, %d rejected\n", result.Checked, result.violations())
+	return err
+}
+
+// writeJSON 输出 lintResult 的 JSON 表示。
+func writeJSON(w io.Writer, result *lintResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// SARIF 2.1.0 中用到的最小字段集合。
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// writeSARIF 以 SARIF 2.1.0 格式输出结果，便于代码扫描平台展示。
+func writeSARIF(w io.Writer, result *lintResult) error {
+	results := make([]sarifResult, 0, len(result.Findings))
+	for _, f := range result.Findings {
+		results = append(results, sarifResult{
+			RuleID:  ruleID,
+			Level:   f.Level,
+			Message: sarifMessage{Text: fmt.Sprintf("%s %s/%s: %s", f.Kind, f.Namespace, f.Name, f.Message)},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(f.Path)},
+				Region:           sarifRegion{StartLine: f.Line},
+			}}},
+		})
+	}
+	log := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           toolName,
+				InformationURI: toolURI,
+				Rules: []sarifRule{{
+					ID:               ruleID,
+					ShortDescription: sarifMessage{Text: "Ingress resources must only reference existing backends"},
+				}},
+			}},
+			Results: results,
+		}},
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
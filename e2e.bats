@@ -20,7 +20,7 @@
 
   echo "output = ${output}"
   [ "$status" -eq 0 ]
-  [ $(echo "${output}" | grep -q "Service 'non-existent-service': host call failed"; echo $?) -eq 0 ]
+  [ $(echo "${output}" | grep -q "Service 'non-existent-service' does not exist in namespace 'default' (referenced by defaultBackend)"; echo $?) -eq 0 ]
 }
 
 @test "allow when validation disabled (skip service check)" {
@@ -43,3 +43,27 @@
   [ "$status" -eq 0 ]
   [ $(expr "$output" : '.*"allowed":true.*') -ne 0 ]
 }
+
+@test "reject existing service with unknown port" {
+  run env RUST_BACKTRACE=1 kwctl run --allow-context-aware --replay-host-capabilities-interactions test_data/replay-session-with-service.yml \
+        -r "test_data/ingress-wrong-port.json" \
+        --settings-json '{"enforce_service_exists": true}' \
+        "annotated-policy.wasm"
+
+  echo "output = ${output}"
+  [ "$status" -eq 0 ]
+  [ $(expr "$output" : '.*"allowed":false.*') -ne 0 ]
+  [ $(echo "${output}" | grep -q "does not expose port 8080"; echo $?) -eq 0 ]
+}
+
+@test "reject multiple missing services in a stable order" {
+  run env RUST_BACKTRACE=1 kwctl run --allow-context-aware --replay-host-capabilities-interactions test_data/replay-session-multiple-missing.yml \
+        -r "test_data/ingress-multiple-missing.json" \
+        --settings-json '{"enforce_service_exists": true}' \
+        "annotated-policy.wasm"
+
+  echo "output = ${output}"
+  [ "$status" -eq 0 ]
+  [ $(expr "$output" : '.*"allowed":false.*') -ne 0 ]
+  [ $(echo "${output}" | grep -q "Service 'alpha-service' does not exist in namespace 'default' (referenced by rules\[0\].http.paths\[2\] (host 'shop.example.com', path '/a')); Service 'zeta-service' does not exist"; echo $?) -eq 0 ]
+}
@@ -9,6 +9,7 @@ require (
 	github.com/kubewarden/k8s-objects v1.29.0-kw1
 	github.com/kubewarden/policy-sdk-go v0.11.1
 	github.com/wapc/wapc-guest-tinygo v0.3.3
+	sigs.k8s.io/yaml v1.4.0
 )
 
 require (
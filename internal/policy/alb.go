@@ -0,0 +1,141 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+)
+
+// annotationControllerAWSLoadBalancer 是 AWS Load Balancer Controller 注解提取器的名称。
+const annotationControllerAWSLoadBalancer = "aws-load-balancer-controller"
+
+const (
+	// albActionAnnotationPrefix 加上 action 名称即为 action 注解键。
+	albActionAnnotationPrefix = "alb.ingress.kubernetes.io/actions."
+	// albUseAnnotationPort 是后端引用 action 注解时使用的端口名。
+	albUseAnnotationPort = "use-annotation"
+	// albActionTypeForward 是把请求转发到目标组的 action 类型。
+	albActionTypeForward = "forward"
+)
+
+// albAction 对应 alb.ingress.kubernetes.io/actions.<name> 注解中的 JSON，只声明用到的字段。
+// encoding/json 匹配字段名时不区分大小写，因此也兼容旧版 Type/ForwardConfig 写法。
+type albAction struct {
+	Type          string            `json:"type"`
+	ForwardConfig *albForwardConfig `json:"forwardConfig"`
+}
+
+// albForwardConfig 是 forward action 的目标组配置。
+type albForwardConfig struct {
+	TargetGroups []albTargetGroup `json:"targetGroups"`
+}
+
+// albTargetGroup 是 forward action 的一个目标组，通过 serviceName/servicePort 指向 Service，
+// 或通过 targetGroupARN/targetGroupName 指向集群外的目标组。权重是相对值，不要求总和为固定值，因此不做校验。
+type albTargetGroup struct {
+	ServiceName     string           `json:"serviceName"`
+	ServicePort     *intOrStringPort `json:"servicePort"`
+	TargetGroupARN  string           `json:"targetGroupARN"`
+	TargetGroupName string           `json:"targetGroupName"`
+}
+
+// isALBActionBackend 判断后端是否通过 port.name: use-annotation 引用 action 注解。
+func isALBActionBackend(backend *networkingv1.IngressBackend) bool {
+	return backend != nil && backend.Service != nil && backend.Service.Port != nil &&
+		backend.Service.Port.Name == albUseAnnotationPort
+}
+
+// isClaimedALBActionBackend 判断 use-annotation 后端是否由 ALB 注解检查负责：启用了
+// aws-load-balancer-controller 提取器时，该检查会通过 actions.<name> 注解解析后端，
+// 或者报告注解缺失，因此它不再作为 Service 引用查询。未启用提取器时该后端仍按普通 Service 引用校验，
+// 不能借 use-annotation 绕过存在性检查。
+func isClaimedALBActionBackend(backend *networkingv1.IngressBackend, settings Settings) bool {
+	return isALBActionBackend(backend) && backend.Service.Name != nil &&
+		annotationControllerEnabled(settings, annotationControllerAWSLoadBalancer)
+}
+
+// extractALBAnnotationReferences 解析全部 alb.ingress.kubernetes.io/actions.<name> 注解，
+// 把 forward action 中的 serviceName/servicePort 作为 Service 引用返回，
+// 并检查每个 use-annotation 后端都有对应的 action 注解。
+func extractALBAnnotationReferences(ing *networkingv1.Ingress) (annotationReferences, []string) {
+	var refs annotationReferences
+	var problems []string
+
+	keys := make([]string, 0, len(ing.Metadata.Annotations))
+	for key := range ing.Metadata.Annotations {
+		if strings.HasPrefix(key, albActionAnnotationPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		actionRefs, actionProblems := albActionServiceReferences(ing.Metadata.Namespace, key, ing.Metadata.Annotations[key])
+		refs.Services = append(refs.Services, actionRefs...)
+		problems = append(problems, actionProblems...)
+	}
+
+	checkBackend := func(backend *networkingv1.IngressBackend, location string) {
+		if !isALBActionBackend(backend) || backend.Service.Name == nil {
+			return
+		}
+		key := albActionAnnotationPrefix + *backend.Service.Name
+		if _, ok := ing.Metadata.Annotations[key]; !ok {
+			problems = append(problems, fmt.Sprintf("%s uses port '%s' but %s is not set",
+				location, albUseAnnotationPort, formatAnnotationLocation(key)))
+		}
+	}
+	if ing.Spec != nil {
+		checkBackend(ing.Spec.DefaultBackend, "defaultBackend")
+		for i, rule := range ing.Spec.Rules {
+			if rule == nil || rule.HTTP == nil {
+				continue
+			}
+			for j, path := range rule.HTTP.Paths {
+				if path != nil {
+					checkBackend(path.Backend, formatPathLocation(i, j, rule.Host, path.Path))
+				}
+			}
+		}
+	}
+	return refs, problems
+}
+
+// albActionServiceReferences 解析一个 action 注解，返回其中的 Service 引用与问题。
+// 非 forward 类型的 action（redirect、fixed-response）不引用 Service。
+func albActionServiceReferences(namespace, key, value string) ([]serviceReference, []string) {
+	location := formatAnnotationLocation(key)
+	var action albAction
+	if err := json.Unmarshal([]byte(value), &action); err != nil {
+		return nil, []string{fmt.Sprintf("%s is invalid: cannot parse action JSON: %s", location, err)}
+	}
+	if !strings.EqualFold(action.Type, albActionTypeForward) || action.ForwardConfig == nil {
+		return nil, nil
+	}
+
+	var refs []serviceReference
+	var problems []string
+	for i, tg := range action.ForwardConfig.TargetGroups {
+		if tg.ServiceName == "" {
+			if tg.TargetGroupARN == "" && tg.TargetGroupName == "" {
+				problems = append(problems, fmt.Sprintf("%s is invalid: targetGroups[%d] sets neither serviceName nor a target group", location, i))
+			}
+			continue
+		}
+		if tg.ServicePort == nil {
+			problems = append(problems, fmt.Sprintf("%s is invalid: targetGroups[%d] sets serviceName '%s' without servicePort",
+				location, i, tg.ServiceName))
+			continue
+		}
+		port := tg.ServicePort.ServiceBackendPort
+		refs = append(refs, serviceReference{
+			Namespace: namespace,
+			Name:      tg.ServiceName,
+			Port:      &port,
+			Location:  fmt.Sprintf("%s targetGroups[%d]", location, i),
+		})
+	}
+	return refs, problems
+}
@@ -0,0 +1,162 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+)
+
+// albActionIngress 构造一个通过 use-annotation 引用 action 的 Ingress。
+func albActionIngress(action string, annotations map[string]string) *networkingv1.Ingress {
+	return &networkingv1.Ingress{
+		Metadata: &metav1.ObjectMeta{Name: "alb-ingress", Namespace: "default", Annotations: annotations},
+		Spec: &networkingv1.IngressSpec{
+			Rules: []*networkingv1.IngressRule{{HTTP: &networkingv1.HTTPIngressRuleValue{Paths: []*networkingv1.HTTPIngressPath{{
+				Path:     "/",
+				PathType: strPtr("Prefix"),
+				Backend: &networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+					Name: strPtr(action),
+					Port: &networkingv1.ServiceBackendPort{Name: "use-annotation"},
+				}},
+			}}}}},
+		},
+	}
+}
+
+func TestALBActionAnnotations(t *testing.T) {
+	const key = "alb.ingress.kubernetes.io/actions.blue-green"
+	tests := []struct {
+		name          string
+		annotations   map[string]string
+		expectMessage string
+	}{
+		{
+			name: "weighted forward to existing services",
+			annotations: map[string]string{key: `{"type":"forward","forwardConfig":{"targetGroups":[` +
+				`{"serviceName":"my-service","servicePort":"80","weight":20},` +
+				`{"serviceName":"my-service","servicePort":"web","weight":80}]}}`},
+		},
+		{
+			name: "legacy capitalised fields with a numeric port",
+			annotations: map[string]string{key: `{"Type":"forward","ForwardConfig":{"TargetGroups":[` +
+				`{"ServiceName":"my-service","ServicePort":8080}]}}`},
+		},
+		{
+			name:        "non-forward action",
+			annotations: map[string]string{key: `{"type":"fixed-response","fixedResponseConfig":{"statusCode":"503"}}`},
+		},
+		{
+			name: "target group outside the cluster",
+			annotations: map[string]string{key: `{"type":"forward","forwardConfig":{"targetGroups":[` +
+				`{"targetGroupARN":"arn:aws:elasticloadbalancing:eu-west-1:123456789012:targetgroup/blue/0123"}]}}`},
+		},
+		{
+			name: "missing service in a target group",
+			annotations: map[string]string{key: `{"type":"forward","forwardConfig":{"targetGroups":[` +
+				`{"serviceName":"green-service","servicePort":80,"weight":50},` +
+				`{"serviceName":"my-service","servicePort":80,"weight":50}]}}`},
+			expectMessage: "Service 'green-service' does not exist in namespace 'default' " +
+				"(referenced by annotation 'alb.ingress.kubernetes.io/actions.blue-green' targetGroups[0])",
+		},
+		{
+			name: "port not exposed by the service",
+			annotations: map[string]string{key: `{"type":"forward","forwardConfig":{"targetGroups":[` +
+				`{"serviceName":"my-service","servicePort":"grpc"}]}}`},
+			expectMessage: "does not expose port 'grpc' referenced by annotation 'alb.ingress.kubernetes.io/actions.blue-green' targetGroups[0]",
+		},
+		{
+			name: "relative weights that do not sum to 100",
+			annotations: map[string]string{key: `{"type":"forward","forwardConfig":{"targetGroups":[` +
+				`{"serviceName":"my-service","servicePort":80,"weight":60},` +
+				`{"serviceName":"my-service","servicePort":8080,"weight":60}]}}`},
+		},
+		{
+			name:          "malformed action JSON",
+			annotations:   map[string]string{key: `{"type":"forward",`},
+			expectMessage: "annotation 'alb.ingress.kubernetes.io/actions.blue-green' is invalid: cannot parse action JSON",
+		},
+		{
+			name:          "backend without an action annotation",
+			expectMessage: "rules[0].http.paths[0] (path '/') uses port 'use-annotation' but annotation 'alb.ingress.kubernetes.io/actions.blue-green' is not set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host.Client = newMockWapcClient(nil)
+			settings := Settings{
+				EnforceServiceExists:           true,
+				AnnotationReferenceControllers: []string{annotationControllerAWSLoadBalancer},
+			}
+			response := validateWithClient(t, albActionIngress("blue-green", tt.annotations), &settings)
+			if tt.expectMessage == "" {
+				if !response.Accepted {
+					t.Errorf("Unexpected rejection: %s", *response.Message)
+				}
+				return
+			}
+			if response.Accepted {
+				t.Fatal("Expected rejection for a broken ALB action")
+			}
+			if !strings.Contains(*response.Message, tt.expectMessage) {
+				t.Errorf("Expected message to contain '%s', got '%s'", tt.expectMessage, *response.Message)
+			}
+		})
+	}
+}
+
+func TestUseAnnotationBackendServiceReference(t *testing.T) {
+	const key = "alb.ingress.kubernetes.io/actions.blue-green"
+	action := map[string]string{key: `{"type":"fixed-response","fixedResponseConfig":{"statusCode":"503"}}`}
+	albEnabled := []string{annotationControllerAWSLoadBalancer}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		controllers []string
+		expectRef   bool
+	}{
+		{name: "extractor enabled and action annotation set", annotations: action, controllers: albEnabled},
+		{name: "extractor enabled without the action annotation", controllers: albEnabled},
+		{name: "extractor disabled", annotations: action, expectRef: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := Settings{AnnotationReferenceControllers: tt.controllers}
+			refs := extractServiceReferences(albActionIngress("blue-green", tt.annotations), settings)
+			if got := len(refs) == 1 && refs[0].Name == "blue-green"; got != tt.expectRef {
+				t.Errorf("Expected a Service reference: %v, got %+v", tt.expectRef, refs)
+			}
+		})
+	}
+}
+
+func TestMissingALBActionIsReportedOnce(t *testing.T) {
+	host.Client = newMockWapcClient(nil)
+	settings := Settings{
+		EnforceServiceExists:           true,
+		AnnotationReferenceControllers: []string{annotationControllerAWSLoadBalancer},
+	}
+	response := validateWithClient(t, albActionIngress("blue-green", nil), &settings)
+	if response.Accepted {
+		t.Fatal("Expected rejection for a use-annotation backend without an action annotation")
+	}
+	expected := "rules[0].http.paths[0] (path '/') uses port 'use-annotation' but " +
+		"annotation 'alb.ingress.kubernetes.io/actions.blue-green' is not set"
+	if *response.Message != expected {
+		t.Errorf("Expected only the missing action to be reported, got '%s'", *response.Message)
+	}
+}
+
+func TestUseAnnotationBackendCheckedWithDefaultSettings(t *testing.T) {
+	host.Client = newMockWapcClient(nil)
+	settings := Settings{EnforceServiceExists: true}
+	response := validateWithClient(t, albActionIngress("does-not-exist", nil), &settings)
+	if response.Accepted {
+		t.Fatal("Expected a use-annotation backend to be checked as a Service when the ALB extractor is disabled")
+	}
+	if !strings.Contains(*response.Message, "Service 'does-not-exist' does not exist in namespace 'default'") {
+		t.Errorf("Unexpected message: %s", *response.Message)
+	}
+}
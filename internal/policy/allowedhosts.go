@@ -0,0 +1,111 @@
+package policy
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+)
+
+// hostClaim 描述 Ingress 中出现的一个主机名及其位置。
+type hostClaim struct {
+	Host     string
+	Location string
+}
+
+// extractHostClaims 按出现顺序收集 spec.rules[].host 与 spec.tls[].hosts 中的主机名。
+func extractHostClaims(ing *networkingv1.Ingress) []hostClaim {
+	if ing == nil || ing.Spec == nil {
+		return nil
+	}
+	claims := extractRuleHostClaims(ing)
+	for i, tls := range ing.Spec.TLS {
+		if tls == nil {
+			continue
+		}
+		for j, h := range tls.Hosts {
+			if h != "" {
+				claims = append(claims, hostClaim{Host: h, Location: fmt.Sprintf("tls[%d].hosts[%d]", i, j)})
+			}
+		}
+	}
+	return claims
+}
+
+// checkAllowedHosts 校验 Ingress 的规则主机与 TLS 主机匹配命名空间允许的主机 glob。
+// 命名空间通过 allowed_hosts_annotation 指定的注解声明允许的主机，未声明时使用 allowed_hosts。
+// 注解存在但为空时不允许任何主机；注解与 allowed_hosts 都没有配置时不做限制。
+func checkAllowedHosts(ingress *networkingv1.Ingress, settings Settings, report *validationReport) {
+	claims := extractHostClaims(ingress)
+	if len(claims) == 0 {
+		return
+	}
+	namespace := ingress.Metadata.Namespace
+	patterns, source, restricted, err := allowedHostPatterns(namespace, settings)
+	if err != nil {
+		report.addHostError(fmt.Sprintf("Namespace '%s'", namespace), "", err)
+		return
+	}
+	if !restricted {
+		return
+	}
+	allowed := "none"
+	if len(patterns) > 0 {
+		allowed = strings.Join(patterns, ", ")
+	}
+	for _, claim := range claims {
+		matched, err := hostMatchesAny(claim.Host, patterns)
+		if err != nil {
+			report.addViolation("cannot check host '%s' of %s: invalid pattern in %s: %s",
+				claim.Host, claim.Location, source, err)
+			continue
+		}
+		if !matched {
+			report.addViolation("host '%s' of %s is not allowed in namespace '%s' (allowed by %s: %s)",
+				claim.Host, claim.Location, namespace, source, allowed)
+		}
+	}
+}
+
+// allowedHostPatterns 返回命名空间允许的主机 glob 及其来源说明。
+// 第三个返回值为 false 表示该命名空间不受限制；命名空间注解存在时即使取值为空也受限制，
+// 避免空注解让命名空间绕过 allowed_hosts。
+func allowedHostPatterns(namespace string, settings Settings) ([]string, string, bool, error) {
+	if settings.AllowedHostsAnnotation != "" {
+		ns, err := getNamespace(namespace, settings)
+		if err != nil {
+			return nil, "", false, err
+		}
+		if ns.Metadata != nil {
+			if value, ok := ns.Metadata.Annotations[settings.AllowedHostsAnnotation]; ok {
+				return parseHostPatterns(value),
+					fmt.Sprintf("Namespace annotation '%s'", settings.AllowedHostsAnnotation), true, nil
+			}
+		}
+	}
+	return settings.AllowedHosts, "allowed_hosts setting", len(settings.AllowedHosts) > 0, nil
+}
+
+// parseHostPatterns 解析以逗号或空白分隔的主机 glob 列表。
+func parseHostPatterns(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t'
+	})
+}
+
+// hostMatchesAny 判断主机名是否匹配任意 glob，匹配不区分大小写。
+// glob 使用 path.Match 语义，例如 *.team-a.example.com 匹配其下任意深度的子域名。
+func hostMatchesAny(host string, patterns []string) (bool, error) {
+	host = normalizeHost(host)
+	for _, pattern := range patterns {
+		matched, err := path.Match(normalizeHost(pattern), host)
+		if err != nil {
+			return false, fmt.Errorf("'%s': %w", pattern, err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
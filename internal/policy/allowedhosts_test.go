@@ -0,0 +1,112 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+)
+
+const allowedHostsAnnotationKey = "ingress.example.com/allowed-hosts"
+
+// allowedHostsFixtures 返回通过注解声明允许主机的命名空间。
+func allowedHostsFixtures() mockResources {
+	return mockResources{}.
+		addNamespace("team-a", nil, map[string]string{allowedHostsAnnotationKey: "*.team-a.example.com, team-a.example.com"}).
+		addNamespace("locked", nil, map[string]string{allowedHostsAnnotationKey: " , "})
+}
+
+// hostsIngress 构造只包含规则主机与 TLS 主机、没有后端的 Ingress。
+func hostsIngress(namespace string, ruleHosts []string, tlsHosts []string) *networkingv1.Ingress {
+	rules := make([]*networkingv1.IngressRule, 0, len(ruleHosts))
+	for _, h := range ruleHosts {
+		rules = append(rules, &networkingv1.IngressRule{Host: h})
+	}
+	ingress := &networkingv1.Ingress{
+		Metadata: &metav1.ObjectMeta{Name: "test-ingress", Namespace: namespace},
+		Spec:     &networkingv1.IngressSpec{Rules: rules},
+	}
+	if len(tlsHosts) > 0 {
+		ingress.Spec.TLS = []*networkingv1.IngressTLS{{Hosts: tlsHosts}}
+	}
+	return ingress
+}
+
+func TestAllowedHosts(t *testing.T) {
+	tests := []struct {
+		name          string
+		ingress       *networkingv1.Ingress
+		expectMessage string
+	}{
+		{
+			name:    "hosts allowed by namespace annotation",
+			ingress: hostsIngress("team-a", []string{"API.team-a.example.com", "team-a.example.com"}, []string{"*.team-a.example.com"}),
+		},
+		{
+			name:    "rule host outside the namespace zone",
+			ingress: hostsIngress("team-a", []string{"api.team-b.example.com"}, nil),
+			expectMessage: "host 'api.team-b.example.com' of rules[0] is not allowed in namespace 'team-a' " +
+				"(allowed by Namespace annotation 'ingress.example.com/allowed-hosts': *.team-a.example.com, team-a.example.com)",
+		},
+		{
+			name:          "tls host outside the namespace zone",
+			ingress:       hostsIngress("team-a", []string{"www.team-a.example.com"}, []string{"www.example.com"}),
+			expectMessage: "host 'www.example.com' of tls[0].hosts[0] is not allowed in namespace 'team-a'",
+		},
+		{
+			name:    "namespace without annotation uses the fallback list",
+			ingress: hostsIngress("default", []string{"shop.apps.example.com"}, nil),
+		},
+		{
+			name:    "empty namespace annotation allows no hosts",
+			ingress: hostsIngress("locked", []string{"shop.apps.example.com"}, nil),
+			expectMessage: "host 'shop.apps.example.com' of rules[0] is not allowed in namespace 'locked' " +
+				"(allowed by Namespace annotation 'ingress.example.com/allowed-hosts': none)",
+		},
+		{
+			name:          "fallback list rejects other hosts",
+			ingress:       hostsIngress("default", []string{"shop.example.org"}, nil),
+			expectMessage: "host 'shop.example.org' of rules[0] is not allowed in namespace 'default' (allowed by allowed_hosts setting: *.apps.example.com)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host.Client = newMockWapcClient(allowedHostsFixtures())
+			settings := Settings{
+				EnforceServiceExists:   true,
+				AllowedHostsAnnotation: allowedHostsAnnotationKey,
+				AllowedHosts:           []string{"*.apps.example.com"},
+			}
+			response := validateWithClient(t, tt.ingress, &settings)
+			if tt.expectMessage == "" {
+				if !response.Accepted {
+					t.Errorf("Unexpected rejection: %s", *response.Message)
+				}
+				return
+			}
+			if response.Accepted {
+				t.Fatal("Expected rejection for a host outside the allowed list")
+			}
+			if !strings.Contains(*response.Message, tt.expectMessage) {
+				t.Errorf("Expected message to contain '%s', got '%s'", tt.expectMessage, *response.Message)
+			}
+		})
+	}
+}
+
+func TestAllowedHostsWithoutFallbackIsUnrestricted(t *testing.T) {
+	host.Client = newMockWapcClient(allowedHostsFixtures())
+	settings := Settings{EnforceServiceExists: true, AllowedHostsAnnotation: allowedHostsAnnotationKey}
+	if response := validateWithClient(t, hostsIngress("default", []string{"anything.example.org"}, nil), &settings); !response.Accepted {
+		t.Errorf("Unexpected rejection: %s", *response.Message)
+	}
+}
+
+func TestInvalidAllowedHostsPattern(t *testing.T) {
+	settings := Settings{AllowedHosts: []string{"[a-.example.com"}}
+	if valid, err := settings.Valid(); valid || err == nil {
+		t.Error("Expected a malformed allowed_hosts pattern to be rejected")
+	}
+}
@@ -0,0 +1,229 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+)
+
+// annotationSecretReference 描述控制器注解对某个 Secret 的一次引用。
+type annotationSecretReference struct {
+	// Namespace 为 Secret 所在的命名空间。
+	Namespace string
+	// Name 为引用的 Secret 名称。
+	Name string
+	// Annotation 为引用所在的注解键。
+	Annotation string
+}
+
+// annotationReferences 是从控制器注解中提取的全部引用。
+type annotationReferences struct {
+	// Services 与 spec 中的后端一起参与 Service 存在性与端口校验，Location 为注解描述。
+	Services []serviceReference
+	// Secrets 只校验存在性。
+	Secrets []annotationSecretReference
+	// Objects 为对其他类型对象（例如 Traefik Middleware）的引用，只校验存在性。
+	Objects []objectReference
+}
+
+// namespacedName 是对象的命名空间与名称。
+type namespacedName struct {
+	Namespace string
+	Name      string
+}
+
+// objectReference 描述对某个自定义资源的一次引用。Candidates 按顺序查询，任意一个存在即视为有效；
+// 引用写法无法唯一确定命名空间与名称时（例如 Traefik 的 namespace-name@kubernetescrd）会有多个候选。
+type objectReference struct {
+	APIVersion string
+	Kind       string
+	// Display 为引用的原始写法，用于报告。
+	Display    string
+	Candidates []namespacedName
+	// Location 描述引用出现的位置，例如 routes[0].middlewares[1] 或注解描述。
+	Location string
+}
+
+// annotationReferenceExtractor 从某个 Ingress 控制器的注解中提取引用。
+// 注解值无法解析时在第二个返回值中给出问题描述，问题中应包含注解键。
+type annotationReferenceExtractor func(ing *networkingv1.Ingress) (annotationReferences, []string)
+
+// annotationReferenceExtractors 按控制器名称注册注解引用提取器，
+// annotation_reference_controllers 中只能使用这里列出的名称。
+//
+//nolint:gochecknoglobals // 只读的查找表
+var annotationReferenceExtractors = map[string]annotationReferenceExtractor{
+	annotationControllerIngressNginx:    extractNginxAnnotationReferences,
+	annotationControllerAWSLoadBalancer: extractALBAnnotationReferences,
+	annotationControllerTraefik:         extractTraefikAnnotationReferences,
+}
+
+// knownAnnotationControllers 返回排序后的已注册控制器名称。
+func knownAnnotationControllers() []string {
+	names := make([]string, 0, len(annotationReferenceExtractors))
+	for name := range annotationReferenceExtractors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateAnnotationControllerSettings 校验 annotation_reference_controllers 只包含已注册的控制器。
+func validateAnnotationControllerSettings(s *Settings) error {
+	for _, controller := range s.AnnotationReferenceControllers {
+		if _, ok := annotationReferenceExtractors[controller]; !ok {
+			return fmt.Errorf("invalid annotation_reference_controllers entry '%s': must be one of %s",
+				controller, strings.Join(knownAnnotationControllers(), ", "))
+		}
+	}
+	return nil
+}
+
+// annotationControllerEnabled 判断 annotation_reference_controllers 是否启用了指定控制器。
+func annotationControllerEnabled(settings Settings, controller string) bool {
+	for _, enabled := range settings.AnnotationReferenceControllers {
+		if enabled == controller {
+			return true
+		}
+	}
+	return false
+}
+
+// extractAnnotationReferences 依次运行 annotation_reference_controllers 中启用的提取器，
+// 合并它们提取的引用与解析问题。
+func extractAnnotationReferences(ing *networkingv1.Ingress, settings Settings) (annotationReferences, []string) {
+	var all annotationReferences
+	var problems []string
+	if ing == nil || ing.Metadata == nil {
+		return all, nil
+	}
+	for _, controller := range settings.AnnotationReferenceControllers {
+		extract, ok := annotationReferenceExtractors[controller]
+		if !ok {
+			continue
+		}
+		refs, extractProblems := extract(ing)
+		all.Services = append(all.Services, refs.Services...)
+		all.Secrets = append(all.Secrets, refs.Secrets...)
+		all.Objects = append(all.Objects, refs.Objects...)
+		problems = append(problems, extractProblems...)
+	}
+	return all, problems
+}
+
+// splitNamespacedName 解析注解中 name 或 namespace/name 形式的对象引用，
+// 省略命名空间时使用 defaultNamespace。
+func splitNamespacedName(value, defaultNamespace string) (string, string, error) {
+	value = strings.TrimSpace(value)
+	parts := strings.Split(value, "/")
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		return defaultNamespace, parts[0], nil
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		return parts[0], parts[1], nil
+	default:
+		return "", "", fmt.Errorf("'%s' is not of the form name or namespace/name", value)
+	}
+}
+
+// formatAnnotationLocation 返回注解引用的位置描述。
+func formatAnnotationLocation(annotation string) string {
+	return fmt.Sprintf("annotation '%s'", annotation)
+}
+
+// checkAnnotationSecrets 校验注解引用的 Secret 存在，按命名空间与名称排序后依次查询。
+func checkAnnotationSecrets(settings Settings, refs []annotationSecretReference, report *validationReport) {
+	keys := make([]string, 0, len(refs))
+	byKey := make(map[string][]annotationSecretReference, len(refs))
+	for _, ref := range refs {
+		key := ref.Namespace + "/" + ref.Name
+		if _, ok := byKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], ref)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		secretRefs := byKey[key]
+		namespace, name := secretRefs[0].Namespace, secretRefs[0].Name
+		locations := make([]string, 0, len(secretRefs))
+		for _, ref := range secretRefs {
+			locations = append(locations, formatAnnotationLocation(ref.Annotation))
+		}
+		_, err := getSecret(namespace, name, settings)
+		if errors.Is(err, ErrResourceNotFound) {
+			report.addViolation("Secret '%s' does not exist in namespace '%s' (referenced by %s)",
+				name, namespace, strings.Join(locations, ", "))
+			continue
+		}
+		if err != nil {
+			report.addHostError(fmt.Sprintf("Secret '%s'", name), strings.Join(locations, ", "), err)
+		}
+	}
+}
+
+// checkObjectReferences 校验引用的对象存在，按类型与写法分组排序后依次查询。
+func checkObjectReferences(settings Settings, refs []objectReference, report *validationReport) {
+	keys := make([]string, 0, len(refs))
+	byKey := make(map[string][]objectReference, len(refs))
+	for _, ref := range refs {
+		key := objectReferenceIdentity(ref)
+		if _, ok := byKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], ref)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		objectRefs := byKey[key]
+		ref := objectRefs[0]
+		locations := make([]string, 0, len(objectRefs))
+		for _, r := range objectRefs {
+			locations = append(locations, r.Location)
+		}
+		found, err := anyObjectExists(settings, ref)
+		if err != nil {
+			report.addHostError(fmt.Sprintf("%s '%s'", ref.Kind, ref.Display), strings.Join(locations, ", "), err)
+			continue
+		}
+		if found {
+			continue
+		}
+		if len(ref.Candidates) == 1 {
+			report.addViolation("%s '%s' does not exist in namespace '%s' (referenced by %s)",
+				ref.Kind, ref.Candidates[0].Name, ref.Candidates[0].Namespace, strings.Join(locations, ", "))
+			continue
+		}
+		tried := make([]string, 0, len(ref.Candidates))
+		for _, candidate := range ref.Candidates {
+			tried = append(tried, candidate.Namespace+"/"+candidate.Name)
+		}
+		report.addViolation("%s '%s' does not match any existing %s (tried %s) (referenced by %s)",
+			ref.Kind, ref.Display, ref.Kind, strings.Join(tried, ", "), strings.Join(locations, ", "))
+	}
+}
+
+// anyObjectExists 依次查询引用的候选对象，任意一个存在时返回 true。
+func anyObjectExists(settings Settings, ref objectReference) (bool, error) {
+	for _, candidate := range ref.Candidates {
+		respBytes, err := getResource(getResourceRequest{
+			APIVersion:   ref.APIVersion,
+			Kind:         ref.Kind,
+			Namespace:    candidate.Namespace,
+			Name:         candidate.Name,
+			DisableCache: settings.DisableCache,
+		})
+		if err == nil && len(respBytes) > 0 {
+			return true, nil
+		}
+		if err != nil && !errors.Is(err, ErrResourceNotFound) {
+			return false, err
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,137 @@
+package policy
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+)
+
+const hoursPerDay = 24
+
+// hostCertificate 对应 crypto host capability 中的证书结构。
+type hostCertificate struct {
+	Encoding string `json:"encoding"`
+	Data     []rune `json:"data"`
+}
+
+// certificateVerificationRequest 对应 v1/is_certificate_trusted 的请求参数。
+type certificateVerificationRequest struct {
+	Cert hostCertificate `json:"cert"`
+	// 中间证书在前，根证书在后；为空时视为可信。
+	CertChain []hostCertificate `json:"cert_chain"`
+	// RFC 3339 格式，用于检查证书是否过期。
+	NotAfter string `json:"not_after"`
+}
+
+// certificateVerificationResponse 对应 v1/is_certificate_trusted 的响应。
+type certificateVerificationResponse struct {
+	Trusted bool   `json:"trusted"`
+	Reason  string `json:"reason"`
+}
+
+// parseCertificates 解析 PEM 中的全部证书，第一个为叶子证书，其余为证书链。
+func parseCertificates(data []byte) ([]*x509.Certificate, [][]byte, error) {
+	var certs []*x509.Certificate
+	var pemBlocks [][]byte
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot parse certificate: %w", err)
+		}
+		certs = append(certs, cert)
+		pemBlocks = append(pemBlocks, pem.EncodeToMemory(block))
+	}
+	if len(certs) == 0 {
+		return nil, nil, errors.New("tls.crt does not contain a PEM encoded certificate")
+	}
+	return certs, pemBlocks, nil
+}
+
+// certificateCoversHost 判断证书的 SAN 是否覆盖给定主机名，支持单级通配符。
+// Ingress 中的通配符主机（例如 *.example.com）只能由相同的通配符 SAN 覆盖。
+func certificateCoversHost(cert *x509.Certificate, host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for _, san := range cert.DNSNames {
+		san = strings.TrimSuffix(strings.ToLower(san), ".")
+		if san == host {
+			return true
+		}
+		if !strings.HasPrefix(san, "*.") {
+			continue
+		}
+		label, rest, found := strings.Cut(host, ".")
+		if found && label != "" && label != "*" && rest == san[2:] {
+			return true
+		}
+	}
+	return false
+}
+
+// certificateValidityProblem 检查证书有效期，返回问题描述；没有问题时返回空字符串。
+func certificateValidityProblem(cert *x509.Certificate, now time.Time, minValidityDays int) string {
+	if now.Before(cert.NotBefore) {
+		return fmt.Sprintf("certificate is not valid before %s", cert.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if now.After(cert.NotAfter) {
+		return fmt.Sprintf("certificate expired on %s", cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+	if minValidityDays > 0 && cert.NotAfter.Before(now.Add(time.Duration(minValidityDays)*hoursPerDay*time.Hour)) {
+		return fmt.Sprintf("certificate expires on %s, within min_cert_validity_days=%d",
+			cert.NotAfter.UTC().Format(time.RFC3339), minValidityDays)
+	}
+	return ""
+}
+
+// verifyCertificateChain 通过 Kubewarden crypto host capability 校验叶子证书与其证书链。
+func verifyCertificateChain(leaf []byte, chain [][]byte, notAfter time.Time) (*certificateVerificationResponse, error) {
+	req := certificateVerificationRequest{
+		Cert:      hostCertificate{Encoding: "Pem", Data: []rune(string(leaf))},
+		CertChain: make([]hostCertificate, 0, len(chain)),
+		NotAfter:  notAfter.UTC().Format(time.RFC3339),
+	}
+	for _, cert := range chain {
+		req.CertChain = append(req.CertChain, hostCertificate{Encoding: "Pem", Data: []rune(string(cert))})
+	}
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal certificate verification request: %w", err)
+	}
+
+	respBytes, err := host.Client.HostCall("kubewarden", "crypto", "v1/is_certificate_trusted", reqBytes)
+	if err != nil {
+		return nil, classifyHostError(err)
+	}
+	resp := &certificateVerificationResponse{}
+	if err := json.Unmarshal(respBytes, resp); err != nil {
+		return nil, fmt.Errorf("cannot decode certificate verification response: %w", err)
+	}
+	return resp, nil
+}
+
+// extractRuleHosts 收集 spec.rules 中声明的主机名（不去重）。
+func extractRuleHosts(ing *networkingv1.Ingress) []string {
+	if ing == nil || ing.Spec == nil {
+		return nil
+	}
+	var hosts []string
+	for _, rule := range ing.Spec.Rules {
+		if rule != nil && rule.Host != "" {
+			hosts = append(hosts, rule.Host)
+		}
+	}
+	return hosts
+}
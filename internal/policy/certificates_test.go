@@ -0,0 +1,191 @@
+package policy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+)
+
+// generateCertificate 生成一个自签名证书，返回 PEM 编码。
+func generateCertificate(t *testing.T, dnsNames []string, notBefore, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		DNSNames:     dnsNames,
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("cannot create certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// tlsSecretJSON 构造包含给定证书的 kubernetes.io/tls Secret。
+func tlsSecretJSON(name string, certPEM []byte) string {
+	return fmt.Sprintf(`{"kind":"Secret","apiVersion":"v1","metadata":{"name":"%s","namespace":"default"},`+
+		`"type":"kubernetes.io/tls","data":{"tls.crt":"%s","tls.key":"dGVzdA=="}}`,
+		name, base64.StdEncoding.EncodeToString(certPEM))
+}
+
+// certIngress 构造一个 TLS 条目与规则都声明了 foo.example.com 的 Ingress。
+func certIngress(secretName string, ruleHosts ...string) networkingv1.Ingress {
+	ingress := tlsIngress(secretName)
+	for _, h := range ruleHosts {
+		ingress.Spec.Rules = append(ingress.Spec.Rules, &networkingv1.IngressRule{Host: h})
+	}
+	return ingress
+}
+
+func certSettings() Settings {
+	return Settings{
+		EnforceServiceExists:   true,
+		EnforceTLSSecretExists: true,
+		VerifyTLSCertificates:  true,
+	}
+}
+
+func TestCertificateCoversHost(t *testing.T) {
+	cert := &x509.Certificate{DNSNames: []string{"example.com", "*.apps.example.com"}}
+	tests := []struct {
+		host     string
+		expected bool
+	}{
+		{"example.com", true},
+		{"EXAMPLE.com.", true},
+		{"web.apps.example.com", true},
+		{"a.b.apps.example.com", false},
+		{"apps.example.com", false},
+		{"*.apps.example.com", true},
+		{"*.example.com", false},
+	}
+	for _, tt := range tests {
+		if got := certificateCoversHost(cert, tt.host); got != tt.expected {
+			t.Errorf("certificateCoversHost(%s) = %v, expected %v", tt.host, got, tt.expected)
+		}
+	}
+}
+
+func TestValidCertificateIsAccepted(t *testing.T) {
+	now := time.Now()
+	certPEM := generateCertificate(t, []string{"*.example.com"}, now.Add(-time.Hour), now.Add(90*24*time.Hour))
+	host.Client = newMockWapcClient(map[string]string{
+		mockResourceKey("Secret", "default", "cert-secret"): tlsSecretJSON("cert-secret", certPEM),
+	})
+
+	ingress := certIngress("cert-secret", "foo.example.com")
+	settings := certSettings()
+	settings.MinCertValidityDays = 30
+	response := validateWithClient(t, &ingress, &settings)
+	if !response.Accepted {
+		t.Errorf("Unexpected rejection: %s", *response.Message)
+	}
+}
+
+func TestCertificateNotCoveringHostsIsRejected(t *testing.T) {
+	now := time.Now()
+	certPEM := generateCertificate(t, []string{"foo.example.com"}, now.Add(-time.Hour), now.Add(90*24*time.Hour))
+	host.Client = newMockWapcClient(map[string]string{
+		mockResourceKey("Secret", "default", "cert-secret"): tlsSecretJSON("cert-secret", certPEM),
+	})
+
+	ingress := certIngress("cert-secret", "foo.example.com", "bar.example.com")
+	ingress.Spec.TLS[0].Hosts = []string{"foo.example.com", "www.example.com"}
+	settings := certSettings()
+	response := validateWithClient(t, &ingress, &settings)
+	if response.Accepted {
+		t.Fatal("Expected rejection when the certificate does not cover the hosts")
+	}
+	for _, expected := range []string{
+		"does not cover host 'www.example.com' (certificate SANs: foo.example.com) (referenced by tls[0])",
+		"host 'bar.example.com' of rules[1] is not covered by any TLS certificate of the Ingress",
+	} {
+		if !strings.Contains(*response.Message, expected) {
+			t.Errorf("Expected message to contain '%s', got '%s'", expected, *response.Message)
+		}
+	}
+}
+
+func TestUncoveredHostReportsItsRuleIndex(t *testing.T) {
+	now := time.Now()
+	certPEM := generateCertificate(t, []string{"foo.example.com"}, now.Add(-time.Hour), now.Add(90*24*time.Hour))
+	host.Client = newMockWapcClient(map[string]string{
+		mockResourceKey("Secret", "default", "cert-secret"): tlsSecretJSON("cert-secret", certPEM),
+	})
+
+	// rules[0] 没有主机名，未覆盖的主机必须按其在 spec.rules 中的真实下标报告。
+	ingress := certIngress("cert-secret", "", "foo.example.com", "bar.example.com")
+	ingress.Spec.TLS[0].Hosts = []string{"foo.example.com"}
+	settings := certSettings()
+	response := validateWithClient(t, &ingress, &settings)
+	if response.Accepted {
+		t.Fatal("Expected rejection when a rule host is not covered")
+	}
+	expected := "host 'bar.example.com' of rules[2] is not covered by any TLS certificate of the Ingress"
+	if !strings.Contains(*response.Message, expected) {
+		t.Errorf("Expected message to contain '%s', got '%s'", expected, *response.Message)
+	}
+}
+
+func TestExpiringCertificatesAreRejected(t *testing.T) {
+	now := time.Now()
+	expired := generateCertificate(t, []string{"foo.example.com"}, now.Add(-48*time.Hour), now.Add(-24*time.Hour))
+	expiring := generateCertificate(t, []string{"foo.example.com"}, now.Add(-time.Hour), now.Add(5*24*time.Hour))
+	host.Client = newMockWapcClient(map[string]string{
+		mockResourceKey("Secret", "default", "expired"):  tlsSecretJSON("expired", expired),
+		mockResourceKey("Secret", "default", "expiring"): tlsSecretJSON("expiring", expiring),
+	})
+
+	settings := certSettings()
+	settings.MinCertValidityDays = 14
+
+	ingress := certIngress("expired")
+	response := validateWithClient(t, &ingress, &settings)
+	if response.Accepted || !strings.Contains(*response.Message, "certificate expired on") {
+		t.Errorf("Expected rejection for expired certificate, got accepted=%v", response.Accepted)
+	}
+
+	ingress = certIngress("expiring")
+	response = validateWithClient(t, &ingress, &settings)
+	if response.Accepted || !strings.Contains(*response.Message, "within min_cert_validity_days=14") {
+		t.Errorf("Expected rejection for certificate expiring soon, got accepted=%v", response.Accepted)
+	}
+}
+
+func TestUntrustedCertificateChainIsRejected(t *testing.T) {
+	now := time.Now()
+	leaf := generateCertificate(t, []string{"foo.example.com"}, now.Add(-time.Hour), now.Add(90*24*time.Hour))
+	intermediate := generateCertificate(t, []string{"ca.example.com"}, now.Add(-time.Hour), now.Add(365*24*time.Hour))
+	client := newMockWapcClient(map[string]string{
+		mockResourceKey("Secret", "default", "chain"): tlsSecretJSON("chain", append(leaf, intermediate...)),
+	})
+	client.untrustedReason = "certificate signed by unknown authority"
+	host.Client = client
+
+	ingress := certIngress("chain")
+	settings := certSettings()
+	response := validateWithClient(t, &ingress, &settings)
+	if response.Accepted {
+		t.Fatal("Expected rejection for untrusted certificate chain")
+	}
+	if !strings.Contains(*response.Message, "not trusted by its chain: certificate signed by unknown authority") {
+		t.Errorf("Unexpected message: %s", *response.Message)
+	}
+}
@@ -0,0 +1,109 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	onelog "github.com/francoispqt/onelog"
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+// serviceReferenceIdentity 返回判断引用是否“新增”时使用的标识：命名空间、Service、端口与 targetPort。
+// 引用位置不参与比较，把已有后端移动到其他路径不视为新增。
+func serviceReferenceIdentity(ref serviceReference) string {
+	return fmt.Sprintf("%s/%s:%s:%s", ref.Namespace, ref.Name, backendPortIdentity(ref.Port), backendPortIdentity(ref.TargetPort))
+}
+
+// backendPortIdentity 返回端口的比较标识，未设置端口时为空字符串。
+func backendPortIdentity(port *networkingv1.ServiceBackendPort) string {
+	if port == nil || (port.Number == 0 && port.Name == "") {
+		return ""
+	}
+	return formatBackendPort(port)
+}
+
+// annotationSecretIdentity 返回注解 Secret 引用的比较标识。注解键不参与比较。
+func annotationSecretIdentity(ref annotationSecretReference) string {
+	return ref.Namespace + "/" + ref.Name
+}
+
+// objectReferenceIdentity 返回对象引用的比较标识，与 checkObjectReferences 的分组方式一致。
+func objectReferenceIdentity(ref objectReference) string {
+	return fmt.Sprintf("%s/%s|%s|%v", ref.APIVersion, ref.Kind, ref.Display, ref.Candidates)
+}
+
+// tlsSecretIdentity 返回 TLS 引用的比较标识：Secret 名称与条目声明的主机。
+// 主机变化时证书需要覆盖新的主机，因此视为新增。
+func tlsSecretIdentity(ref tlsSecretReference) string {
+	hosts := append([]string(nil), ref.Hosts...)
+	sort.Strings(hosts)
+	return ref.Name + "|" + strings.Join(hosts, ",")
+}
+
+// hostClaimIdentity 返回主机的比较标识，主机所在位置不参与比较。
+func hostClaimIdentity(claim hostClaim) string {
+	return normalizeHost(claim.Host)
+}
+
+// stringIdentity 按原样比较字符串，例如注解解析问题或 Secret 名称：旧对象中出现过完全相同的值即视为已存在。
+func stringIdentity(value string) string {
+	return value
+}
+
+// splitUnchanged 按 identity 将 refs 拆分为本次新增的引用与 oldRefs 中已存在的引用。
+func splitUnchanged[T any](refs, oldRefs []T, identity func(T) string) ([]T, []T) {
+	existing := make(map[string]struct{}, len(oldRefs))
+	for _, ref := range oldRefs {
+		existing[identity(ref)] = struct{}{}
+	}
+	var added, unchanged []T
+	for _, ref := range refs {
+		if _, ok := existing[identity(ref)]; ok {
+			unchanged = append(unchanged, ref)
+		} else {
+			added = append(added, ref)
+		}
+	}
+	return added, unchanged
+}
+
+// checkChangedSeparately 用于 validate_only_changed_backends 模式：与 oldRefs 相同的引用照常检查，
+// 但问题写入 preExisting，只作为已存在问题报告、不阻止请求；新增引用的问题写入 report。
+// 旧对象不存在或无法解析时 oldRefs 为 nil，全部引用按新增处理。
+func checkChangedSeparately[T any](
+	refs, oldRefs []T,
+	identity func(T) string,
+	report, preExisting *validationReport,
+	check func([]T, *validationReport),
+) {
+	added, unchanged := splitUnchanged(refs, oldRefs, identity)
+	if len(unchanged) > 0 {
+		check(unchanged, preExisting)
+	}
+	if len(added) > 0 {
+		check(added, report)
+	}
+}
+
+// decodeOldObject 在开启 validate_only_changed_backends 时解析 UPDATE 请求中的旧对象，用于找出未变化的引用。
+// 其他情况或旧对象无法解析时返回 nil，此时所有引用都会被强制校验。
+func decodeOldObject[T any](
+	settings Settings,
+	validationRequest *kubewarden_protocol.ValidationRequest,
+	decode func(json.RawMessage) (*T, error),
+) *T {
+	if !settings.ValidateOnlyChangedBackends || validationRequest.Request.Operation != operationUpdate {
+		return nil
+	}
+	old, err := decode(validationRequest.Request.OldObject)
+	if err != nil {
+		logger.DebugWithFields("cannot decode old object, validating every backend", func(e onelog.Entry) {
+			e.String("error", err.Error())
+		})
+		return nil
+	}
+	return old
+}
@@ -0,0 +1,244 @@
+package policy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+// pathIngress 构造一个每个 Service 对应一条路径、端口均为 80 的 Ingress。
+func pathIngress(services ...string) *networkingv1.Ingress {
+	paths := make([]*networkingv1.HTTPIngressPath, 0, len(services))
+	for _, service := range services {
+		paths = append(paths, &networkingv1.HTTPIngressPath{
+			Path:     "/" + service,
+			PathType: strPtr("Prefix"),
+			Backend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: strPtr(service),
+					Port: &networkingv1.ServiceBackendPort{Number: 80},
+				},
+			},
+		})
+	}
+	return &networkingv1.Ingress{
+		Metadata: &metav1.ObjectMeta{Name: "test-ingress", Namespace: "default"},
+		Spec: &networkingv1.IngressSpec{
+			Rules: []*networkingv1.IngressRule{{HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths}}},
+		},
+	}
+}
+
+// validateIngressUpdate 以 UPDATE 操作构造 Ingress 请求并执行 validate。
+func validateIngressUpdate(t *testing.T, ingress, oldIngress *networkingv1.Ingress, settings *Settings) kubewarden_protocol.ValidationResponse {
+	t.Helper()
+	return validateKindUpdate(t, ingressAPIGroup, ingressKind, ingress, oldIngress, settings)
+}
+
+// validateKindUpdate 以 UPDATE 操作构造指定 API 组与类型的请求并执行 validate。
+func validateKindUpdate(
+	t *testing.T, group, kind string, object, oldObject interface{}, settings *Settings,
+) kubewarden_protocol.ValidationResponse {
+	t.Helper()
+	objectRaw, err := json.Marshal(object)
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	oldObjectRaw, err := json.Marshal(oldObject)
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	settingsRaw, err := json.Marshal(settings)
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	payload, err := json.Marshal(kubewarden_protocol.ValidationRequest{
+		Request: kubewarden_protocol.KubernetesAdmissionRequest{
+			Uid:       "test-uid",
+			Kind:      kubewarden_protocol.GroupVersionKind{Group: group, Kind: kind},
+			Operation: operationUpdate,
+			Object:    objectRaw,
+			OldObject: oldObjectRaw,
+		},
+		Settings: settingsRaw,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	responsePayload, err := Validate(payload)
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	var response kubewarden_protocol.ValidationResponse
+	if err = json.Unmarshal(responsePayload, &response); err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	return response
+}
+
+func TestPreExistingBrokenBackendDoesNotBlockUpdate(t *testing.T) {
+	setupTestEnv()
+	oldIngress := pathIngress("my-service", "removed-service")
+	ingress := pathIngress("my-service", "removed-service")
+	ingress.Metadata.Annotations = map[string]string{"example.com/owner": "team-a"}
+
+	settings := Settings{EnforceServiceExists: true, ValidateOnlyChangedBackends: true}
+	response := validateIngressUpdate(t, ingress, oldIngress, &settings)
+	if !response.Accepted {
+		t.Fatalf("Expected update to be accepted, got: %s", *response.Message)
+	}
+	expected := "Pre-existing problems not enforced on update: Service 'removed-service' does not exist in namespace 'default'"
+	if response.Message == nil || !strings.Contains(*response.Message, expected) {
+		t.Errorf("Expected message to contain '%s', got %v", expected, response.Message)
+	}
+
+	// 未开启该模式时，已存在的问题照常阻止更新
+	settings.ValidateOnlyChangedBackends = false
+	if response = validateIngressUpdate(t, ingress, oldIngress, &settings); response.Accepted {
+		t.Error("Expected rejection when validate_only_changed_backends is disabled")
+	}
+}
+
+func TestNewlyAddedBrokenBackendBlocksUpdate(t *testing.T) {
+	setupTestEnv()
+	oldIngress := pathIngress("my-service", "removed-service")
+	ingress := pathIngress("my-service", "removed-service", "typo-service")
+
+	settings := Settings{EnforceServiceExists: true, ValidateOnlyChangedBackends: true}
+	response := validateIngressUpdate(t, ingress, oldIngress, &settings)
+	if response.Accepted {
+		t.Fatal("Expected rejection for a newly added missing Service")
+	}
+	if !strings.Contains(*response.Message, "Service 'typo-service' does not exist in namespace 'default'") {
+		t.Errorf("Expected the new reference to be rejected, got: %s", *response.Message)
+	}
+	if !strings.Contains(*response.Message, "Pre-existing problems not enforced on update: Service 'removed-service'") {
+		t.Errorf("Expected the pre-existing reference to be reported separately, got: %s", *response.Message)
+	}
+}
+
+func TestSplitUnchangedServiceReferences(t *testing.T) {
+	oldRefs := extractServiceReferences(pathIngress("my-service", "other-service"), Settings{})
+	ingress := pathIngress("other-service", "my-service")
+	// 端口变化视为新增引用
+	ingress.Spec.Rules[0].HTTP.Paths[1].Backend.Service.Port = &networkingv1.ServiceBackendPort{Name: "web"}
+
+	added, unchanged := splitUnchanged(extractServiceReferences(ingress, Settings{}), oldRefs, serviceReferenceIdentity)
+	if len(added) != 1 || added[0].Name != "my-service" {
+		t.Errorf("Expected only my-service:'web' to be new, got %+v", added)
+	}
+	if len(unchanged) != 1 || unchanged[0].Name != "other-service" {
+		t.Errorf("Expected other-service to be unchanged, got %+v", unchanged)
+	}
+}
+
+func TestPreExistingIngressReferencesOfEveryTypeDoNotBlockUpdate(t *testing.T) {
+	setupTestEnv()
+	oldIngress := resourceIngress(nil, resourceBackend("k8s.example.com", "StorageBucket", "removed-bucket"))
+	oldIngress.Metadata.Annotations = map[string]string{"nginx.ingress.kubernetes.io/auth-secret": "removed-auth"}
+	oldIngress.Spec.TLS = []*networkingv1.IngressTLS{{Hosts: []string{"static.example.com"}, SecretName: "removed-cert"}}
+	ingress := resourceIngress(nil, resourceBackend("k8s.example.com", "StorageBucket", "removed-bucket"))
+	ingress.Metadata.Annotations = map[string]string{
+		"nginx.ingress.kubernetes.io/auth-secret": "removed-auth",
+		"example.com/owner":                       "team-a",
+	}
+	ingress.Spec.TLS = []*networkingv1.IngressTLS{{Hosts: []string{"static.example.com"}, SecretName: "removed-cert"}}
+
+	settings := resourceBackendSettings()
+	settings.ValidateOnlyChangedBackends = true
+	settings.EnforceTLSSecretExists = true
+	settings.AnnotationReferenceControllers = []string{annotationControllerIngressNginx}
+	response := validateIngressUpdate(t, ingress, oldIngress, &settings)
+	if !response.Accepted {
+		t.Fatalf("Expected update to be accepted, got: %s", *response.Message)
+	}
+	for _, expected := range []string{
+		"Secret 'removed-auth' does not exist in namespace 'default'",
+		"TLS Secret 'removed-cert' does not exist in namespace 'default'",
+		"resource backend StorageBucket 'removed-bucket' (apiGroup 'k8s.example.com') does not exist",
+	} {
+		if response.Message == nil || !strings.Contains(*response.Message, expected) {
+			t.Errorf("Expected pre-existing message to contain '%s', got %v", expected, response.Message)
+		}
+	}
+
+	// 新增的 TLS 条目仍然强制校验
+	ingress.Spec.TLS = append(ingress.Spec.TLS, &networkingv1.IngressTLS{Hosts: []string{"static.example.com"}, SecretName: "typo-cert"})
+	response = validateIngressUpdate(t, ingress, oldIngress, &settings)
+	if response.Accepted {
+		t.Fatal("Expected rejection for a newly added missing TLS Secret")
+	}
+	if !strings.HasPrefix(*response.Message, "TLS Secret 'typo-cert' does not exist in namespace 'default'") {
+		t.Errorf("Expected only the new TLS Secret to be enforced, got: %s", *response.Message)
+	}
+}
+
+func TestPreExistingRouteReferencesDoNotBlockUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		group  string
+		kind   string
+		object json.RawMessage
+	}{
+		{
+			name:  "traefik ingress route",
+			group: traefikAPIGroup,
+			kind:  ingressRouteKind,
+			object: json.RawMessage(`{"apiVersion":"traefik.io/v1alpha1","kind":"IngressRoute",` +
+				`"metadata":{"name":"test-route","namespace":"default"},"spec":{"routes":[{` +
+				`"services":[{"name":"removed-service","port":80}],"middlewares":[{"name":"removed-middleware"}]}]}}`),
+		},
+		{
+			name:  "istio virtual service",
+			group: istioNetworkingAPIGroup,
+			kind:  virtualServiceKind,
+			object: json.RawMessage(`{"apiVersion":"networking.istio.io/v1","kind":"VirtualService",` +
+				`"metadata":{"name":"test-vs","namespace":"default"},` +
+				`"spec":{"http":[{"route":[{"destination":{"host":"removed-service"}}]}]}}`),
+		},
+		{
+			name:   "openshift route",
+			group:  openShiftRouteAPIGroup,
+			kind:   openShiftRouteKind,
+			object: openShiftRouteJSON(`{"kind":"Service","name":"removed-service"}`, "", ""),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestEnv()
+			settings := Settings{EnforceServiceExists: true, ValidateOnlyChangedBackends: true}
+			response := validateKindUpdate(t, tt.group, tt.kind, tt.object, tt.object, &settings)
+			if !response.Accepted {
+				t.Fatalf("Expected update to be accepted, got: %s", *response.Message)
+			}
+			expected := "Pre-existing problems not enforced on update: Service 'removed-service' does not exist in namespace 'default'"
+			if response.Message == nil || !strings.Contains(*response.Message, expected) {
+				t.Errorf("Expected message to contain '%s', got %v", expected, response.Message)
+			}
+
+			settings.ValidateOnlyChangedBackends = false
+			if response = validateKindUpdate(t, tt.group, tt.kind, tt.object, tt.object, &settings); response.Accepted {
+				t.Error("Expected rejection when validate_only_changed_backends is disabled")
+			}
+		})
+	}
+}
+
+func TestPreExistingReportKeepsWarnings(t *testing.T) {
+	preExisting := newValidationReport()
+	preExisting.addViolation("Service 'a' does not exist")
+	preExisting.addWarning("Service 'b' has no ready endpoints")
+
+	report := newValidationReport()
+	report.addPreExistingReport(preExisting)
+	if !report.empty() {
+		t.Errorf("Expected pre-existing problems not to block, got %+v", report)
+	}
+	if len(report.preExisting) != 1 || len(report.warnings) != 1 {
+		t.Errorf("Expected one pre-existing problem and one warning, got %+v", report)
+	}
+}
@@ -0,0 +1,160 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+)
+
+// Ingress pathType 取值。
+const (
+	pathTypeExact                  = "Exact"
+	pathTypePrefix                 = "Prefix"
+	pathTypeImplementationSpecific = "ImplementationSpecific"
+)
+
+// routeClaim 描述 Ingress 中一条规则路径占用的 host + path。
+type routeClaim struct {
+	// Key 为规范化后的 host、pathType 与 path，相同 Key 的两条路径由控制器任选其一。
+	Key string
+	// Description 为可读描述，例如 host 'api.example.com' path '/' (Prefix)。
+	Description string
+	// Location 描述路径出现的位置。
+	Location string
+}
+
+// extractRouteClaims 按出现顺序收集 Ingress 中所有规则路径占用的 host + path。
+func extractRouteClaims(ing *networkingv1.Ingress) []routeClaim {
+	if ing == nil || ing.Spec == nil {
+		return nil
+	}
+	var claims []routeClaim
+	for i, rule := range ing.Spec.Rules {
+		if rule == nil || rule.HTTP == nil {
+			continue
+		}
+		host := normalizeHost(rule.Host)
+		for j, path := range rule.HTTP.Paths {
+			if path == nil {
+				continue
+			}
+			pathType := normalizePathType(path.PathType)
+			normalized := normalizePath(path.Path, pathType)
+			displayHost := host
+			if displayHost == "" {
+				displayHost = "*"
+			}
+			claims = append(claims, routeClaim{
+				Key:         host + "|" + pathType + "|" + normalized,
+				Description: fmt.Sprintf("host '%s' path '%s' (%s)", displayHost, normalized, pathType),
+				Location:    formatPathLocation(i, j, rule.Host, path.Path),
+			})
+		}
+	}
+	return claims
+}
+
+// normalizeHost 将主机名转为小写并去掉末尾的点。
+// 通配符主机按字面比较：精确主机的优先级高于通配符，二者同时存在不构成冲突。
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// normalizePathType 返回用于比较的 pathType。主流控制器都按前缀处理
+// ImplementationSpecific，因此与 Prefix 归为一类。
+func normalizePathType(pathType *string) string {
+	if pathType == nil || *pathType == "" || *pathType == pathTypeImplementationSpecific {
+		return pathTypePrefix
+	}
+	return *pathType
+}
+
+// normalizePath 规范化路径：补全开头的 /，并合并重复的 /。
+// Prefix 按路径元素匹配，末尾的 / 不影响语义，因此会被去掉；Exact 保留原样。
+func normalizePath(path, pathType string) string {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	for strings.Contains(path, "//") {
+		path = strings.ReplaceAll(path, "//", "/")
+	}
+	if pathType == pathTypePrefix && len(path) > 1 {
+		path = strings.TrimRight(path, "/")
+	}
+	return path
+}
+
+// checkHostPathCollisions 列出集群内全部 Ingress，拒绝与其他 Ingress 占用相同 host + path 的规则。
+// 被更新的 Ingress 本身不参与比较。
+func checkHostPathCollisions(ingress *networkingv1.Ingress, report *validationReport) {
+	claims := extractRouteClaims(ingress)
+	if len(claims) == 0 {
+		return
+	}
+	ingresses, err := listAllIngresses()
+	if err != nil {
+		report.addHostError("Ingresses in the cluster", "", err)
+		return
+	}
+
+	// 记录其他 Ingress 占用的路径，同一路径只保留第一个出现的 owner
+	owners := map[string]string{}
+	for _, other := range ingresses {
+		if other == nil || other.Metadata == nil || isSameIngress(ingress, other) ||
+			!ingressClassesOverlap(ingress, other) {
+			continue
+		}
+		for _, claim := range extractRouteClaims(other) {
+			if _, ok := owners[claim.Key]; !ok {
+				owners[claim.Key] = fmt.Sprintf("Ingress '%s' in namespace '%s' (%s)",
+					other.Metadata.Name, other.Metadata.Namespace, claim.Location)
+			}
+		}
+	}
+	for _, claim := range claims {
+		if owner, ok := owners[claim.Key]; ok {
+			report.addViolation("%s of %s is already claimed by %s", claim.Description, claim.Location, owner)
+		}
+	}
+}
+
+// isSameIngress 判断两个 Ingress 是否为同一对象。
+func isSameIngress(a, b *networkingv1.Ingress) bool {
+	return a.Metadata.Namespace == b.Metadata.Namespace && a.Metadata.Name == b.Metadata.Name
+}
+
+// ingressClassesOverlap 判断两个 Ingress 是否可能由同一个控制器处理：
+// 显式指定了不同 IngressClass 的 Ingress 可以合法地使用相同的 host + path。
+func ingressClassesOverlap(a, b *networkingv1.Ingress) bool {
+	classA, classB := effectiveIngressClass(a), effectiveIngressClass(b)
+	return classA == "" || classB == "" || classA == classB
+}
+
+// effectiveIngressClass 返回 spec.ingressClassName，未设置时返回旧的 kubernetes.io/ingress.class 注解。
+func effectiveIngressClass(ing *networkingv1.Ingress) string {
+	if ing.Spec != nil && ing.Spec.IngressClassName != "" {
+		return ing.Spec.IngressClassName
+	}
+	if ing.Metadata != nil {
+		return ing.Metadata.Annotations[ingressClassAnnotation]
+	}
+	return ""
+}
+
+// listAllIngresses 通过 host capabilities 列出集群内全部 Ingress。
+func listAllIngresses() ([]*networkingv1.Ingress, error) {
+	respBytes, err := listAllResources(listAllResourcesRequest{
+		APIVersion: "networking.k8s.io/v1",
+		Kind:       "Ingress",
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := ingressList{}
+	if err := json.Unmarshal(respBytes, &list); err != nil {
+		return nil, fmt.Errorf("cannot decode IngressList: %w", err)
+	}
+	return list.Items, nil
+}
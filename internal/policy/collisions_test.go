@@ -0,0 +1,121 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+)
+
+// collisionFixtures 返回其他团队已经占用路径的 Ingress。
+func collisionFixtures() mockResources {
+	return mockResources{}.
+		addIngress(hostPathsIngress("team-b", "api", "API.example.com", "",
+			servicePath("/", "Prefix", "api"), servicePath("/login", "Exact", "login"))).
+		addIngress(hostPathsIngress("team-c", "wildcard", "*.apps.example.com", "",
+			servicePath("/static/", "ImplementationSpecific", "static"))).
+		addIngress(hostPathsIngress("team-d", "internal", "internal.example.com", "traefik",
+			servicePath("/", "Prefix", "internal"))).
+		addIngress(hostPathsIngress("default", "self", "self.example.com", "",
+			servicePath("/", "Prefix", "my-service")))
+}
+
+// servicePath 构造一条指向 Service 80 端口的路径。
+func servicePath(path, pathType, service string) *networkingv1.HTTPIngressPath {
+	return &networkingv1.HTTPIngressPath{
+		Path:     path,
+		PathType: strPtr(pathType),
+		Backend: &networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+			Name: strPtr(service),
+			Port: &networkingv1.ServiceBackendPort{Number: 80},
+		}},
+	}
+}
+
+// hostPathsIngress 构造一个只有一条 host 规则、包含给定路径的 Ingress。
+func hostPathsIngress(
+	namespace, name, host, class string, paths ...*networkingv1.HTTPIngressPath,
+) *networkingv1.Ingress {
+	return &networkingv1.Ingress{
+		Metadata: &metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: &networkingv1.IngressSpec{
+			IngressClassName: class,
+			Rules: []*networkingv1.IngressRule{{
+				Host: host,
+				HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths},
+			}},
+		},
+	}
+}
+
+// hostPathIngress 构造一个只有一条 host + path 规则、指向 my-service 的 Ingress。
+func hostPathIngress(name, host, path, pathType, class string) *networkingv1.Ingress {
+	return hostPathsIngress("default", name, host, class, servicePath(path, pathType, "my-service"))
+}
+
+func TestHostPathCollisions(t *testing.T) {
+	tests := []struct {
+		name          string
+		ingress       *networkingv1.Ingress
+		expectMessage string
+	}{
+		{
+			name:    "same host and prefix in another namespace",
+			ingress: hostPathIngress("mine", "api.example.com.", "/", "Prefix", ""),
+			expectMessage: "host 'api.example.com' path '/' (Prefix) of rules[0].http.paths[0] (host 'api.example.com.', path '/') " +
+				"is already claimed by Ingress 'api' in namespace 'team-b' (rules[0].http.paths[0] (host 'API.example.com', path '/'))",
+		},
+		{
+			name:          "exact path",
+			ingress:       hostPathIngress("mine", "api.example.com", "/login", "Exact", ""),
+			expectMessage: "host 'api.example.com' path '/login' (Exact) of rules[0]",
+		},
+		{
+			name:          "wildcard host with trailing slash and implementation specific path",
+			ingress:       hostPathIngress("mine", "*.apps.example.com", "/static", "Prefix", "nginx"),
+			expectMessage: "is already claimed by Ingress 'wildcard' in namespace 'team-c'",
+		},
+		{name: "prefix and exact on the same path do not collide", ingress: hostPathIngress("mine", "api.example.com", "/login", "Prefix", "")},
+		{name: "longer prefix does not collide", ingress: hostPathIngress("mine", "api.example.com", "/v2", "Prefix", "")},
+		{name: "exact host next to a wildcard does not collide", ingress: hostPathIngress("mine", "www.apps.example.com", "/static", "Prefix", "")},
+		{name: "different ingress classes do not collide", ingress: hostPathIngress("mine", "internal.example.com", "/", "Prefix", "nginx")},
+		{name: "updating the same ingress", ingress: hostPathIngress("self", "self.example.com", "/", "Prefix", "")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host.Client = newMockWapcClient(collisionFixtures())
+			settings := Settings{EnforceServiceExists: true, DetectHostPathCollisions: true}
+			response := validateWithClient(t, tt.ingress, &settings)
+			if tt.expectMessage == "" {
+				if !response.Accepted {
+					t.Errorf("Unexpected rejection: %s", *response.Message)
+				}
+				return
+			}
+			if response.Accepted {
+				t.Fatal("Expected rejection for a host/path collision")
+			}
+			if !strings.Contains(*response.Message, tt.expectMessage) {
+				t.Errorf("Expected message to contain '%s', got '%s'", tt.expectMessage, *response.Message)
+			}
+		})
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		path, pathType, expected string
+	}{
+		{"/", pathTypePrefix, "/"},
+		{"/api/", pathTypePrefix, "/api"},
+		{"api//v1", pathTypePrefix, "/api/v1"},
+		{"/api/", pathTypeExact, "/api/"},
+	}
+	for _, tt := range tests {
+		if got := normalizePath(tt.path, tt.pathType); got != tt.expected {
+			t.Errorf("normalizePath(%q, %q) = %q, expected %q", tt.path, tt.pathType, got, tt.expected)
+		}
+	}
+}
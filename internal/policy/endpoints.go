@@ -0,0 +1,216 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+)
+
+const (
+	// endpointSliceAPIVersion 是 EndpointSlice 的 API 版本。
+	endpointSliceAPIVersion = "discovery.k8s.io/v1"
+	// endpointSliceServiceLabel 是 EndpointSlice 关联 Service 的标签。
+	endpointSliceServiceLabel = "kubernetes.io/service-name"
+	// headlessClusterIP 是 headless Service 的 clusterIP 取值。
+	headlessClusterIP = "None"
+)
+
+// endpointSliceList 对应 discovery.k8s.io/v1 EndpointSliceList 中本策略关心的字段。
+// k8s-objects 的 discovery 类型没有被 vendor，这里只声明需要的部分。
+type endpointSliceList struct {
+	Items []*endpointSlice `json:"items"`
+}
+
+type endpointSlice struct {
+	Endpoints []*sliceEndpoint `json:"endpoints"`
+	Ports     []*slicePort     `json:"ports"`
+}
+
+type sliceEndpoint struct {
+	Conditions *sliceEndpointConditions `json:"conditions,omitempty"`
+}
+
+type sliceEndpointConditions struct {
+	// Ready 为 nil 时按 Kubernetes 约定视为就绪。
+	Ready *bool `json:"ready,omitempty"`
+}
+
+type slicePort struct {
+	Name *string `json:"name,omitempty"`
+}
+
+// serviceEndpoints 汇总一个 Service 的就绪端点：按端点端口名记录是否存在就绪地址。
+type serviceEndpoints struct {
+	// readyPorts 记录存在就绪地址的端口名，未命名端口记为 ""。
+	readyPorts map[string]bool
+	// anyReady 表示是否存在任意就绪地址。
+	anyReady bool
+}
+
+// endpointsCheckSkipped 判断 Service 是否不适用就绪端点检查：
+// ExternalName 没有端点，未设置 selector 的 headless Service 由外部维护端点。
+func endpointsCheckSkipped(service *corev1.Service) bool {
+	if service == nil || service.Spec == nil {
+		return true
+	}
+	if service.Spec.Type == "ExternalName" {
+		return true
+	}
+	return service.Spec.ClusterIP == headlessClusterIP && len(service.Spec.Selector) == 0
+}
+
+// checkReadyEndpoints 校验引用的端口存在就绪端点，问题按 require_ready_endpoints 记为违规或警告。
+func checkReadyEndpoints(settings Settings, service *corev1.Service, refs []serviceReference, report *validationReport) {
+	if endpointsCheckSkipped(service) {
+		return
+	}
+	namespace, name := refs[0].Namespace, refs[0].Name
+	endpoints, err := getServiceEndpoints(namespace, name, settings)
+	if err != nil {
+		report.addHostError(fmt.Sprintf("Endpoints of Service '%s'", name), formatReferenceLocations(refs), err)
+		return
+	}
+
+	// headless Service 可以不声明端口，只要求存在任意就绪地址
+	headless := service.Spec.ClusterIP == headlessClusterIP
+	for _, ref := range refs {
+		if !servicePortExists(service, ref.Port) {
+			// 端口不存在已作为违规报告
+			continue
+		}
+		ready := endpoints.anyReady
+		if !headless && ready {
+			ready = endpoints.portReady(service, ref.Port)
+		}
+		if ready {
+			continue
+		}
+		format := "Service '%s' in namespace '%s' has no ready endpoints for port %s (referenced by %s)"
+		if settings.RequireReadyEndpoints == enforcementModeWarn {
+			report.addWarning(format, name, namespace, formatBackendPort(ref.Port), ref.Location)
+		} else {
+			report.addViolation(format, name, namespace, formatBackendPort(ref.Port), ref.Location)
+		}
+	}
+}
+
+// portReady 判断 backend 端口对应的 Service 端口是否有就绪地址。
+// 端点中的端口以 Service 端口名标识，因此先把 backend 端口解析为 Service 端口。
+func (e *serviceEndpoints) portReady(service *corev1.Service, port *networkingv1.ServiceBackendPort) bool {
+	if port == nil || (port.Number == 0 && port.Name == "") {
+		return e.anyReady
+	}
+	for _, sp := range service.Spec.Ports {
+		if sp == nil {
+			continue
+		}
+		if (port.Number != 0 && sp.Port != nil && *sp.Port == port.Number) || (port.Name != "" && sp.Name == port.Name) {
+			return e.readyPorts[sp.Name]
+		}
+	}
+	return false
+}
+
+// getServiceEndpoints 获取 Service 的就绪端点。优先使用 EndpointSlice，
+// 无法列出或没有任何 EndpointSlice 时回退到旧的 Endpoints 资源。
+func getServiceEndpoints(namespace, name string, settings Settings) (*serviceEndpoints, error) {
+	endpoints, err := getEndpointSlices(namespace, name)
+	if err == nil && endpoints != nil {
+		return endpoints, nil
+	}
+	// 集群不提供 discovery.k8s.io/v1 或没有列出权限时回退，其他错误直接返回
+	if err != nil && !errors.Is(err, ErrResourceNotFound) && !errors.Is(err, ErrForbidden) {
+		return nil, err
+	}
+	return getLegacyEndpoints(namespace, name, settings)
+}
+
+// getEndpointSlices 列出带有 kubernetes.io/service-name 标签的 EndpointSlice，
+// 没有任何 EndpointSlice 时返回 nil。
+func getEndpointSlices(namespace, name string) (*serviceEndpoints, error) {
+	respBytes, err := listResourcesByNamespace(listResourcesByNamespaceRequest{
+		APIVersion:    endpointSliceAPIVersion,
+		Kind:          "EndpointSlice",
+		Namespace:     namespace,
+		LabelSelector: fmt.Sprintf("%s=%s", endpointSliceServiceLabel, name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := endpointSliceList{}
+	if err := json.Unmarshal(respBytes, &list); err != nil {
+		return nil, fmt.Errorf("cannot decode EndpointSliceList: %w", err)
+	}
+	if len(list.Items) == 0 {
+		return nil, nil
+	}
+
+	endpoints := &serviceEndpoints{readyPorts: map[string]bool{}}
+	for _, slice := range list.Items {
+		if slice == nil || !sliceHasReadyEndpoint(slice) {
+			continue
+		}
+		endpoints.anyReady = true
+		for _, port := range slice.Ports {
+			if port == nil {
+				continue
+			}
+			portName := ""
+			if port.Name != nil {
+				portName = *port.Name
+			}
+			endpoints.readyPorts[portName] = true
+		}
+	}
+	return endpoints, nil
+}
+
+// sliceHasReadyEndpoint 判断 EndpointSlice 中是否存在就绪端点。
+func sliceHasReadyEndpoint(slice *endpointSlice) bool {
+	for _, endpoint := range slice.Endpoints {
+		if endpoint == nil {
+			continue
+		}
+		if endpoint.Conditions == nil || endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+			return true
+		}
+	}
+	return false
+}
+
+// getLegacyEndpoints 通过旧的 v1 Endpoints 资源获取就绪端点，资源不存在时视为没有端点。
+func getLegacyEndpoints(namespace, name string, settings Settings) (*serviceEndpoints, error) {
+	endpoints := &serviceEndpoints{readyPorts: map[string]bool{}}
+	respBytes, err := getResource(getResourceRequest{
+		APIVersion:   "v1",
+		Kind:         "Endpoints",
+		Namespace:    namespace,
+		Name:         name,
+		DisableCache: settings.DisableCache,
+	})
+	if errors.Is(err, ErrResourceNotFound) || (err == nil && len(respBytes) == 0) {
+		return endpoints, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	legacy := &corev1.Endpoints{}
+	if err := json.Unmarshal(respBytes, legacy); err != nil {
+		return nil, fmt.Errorf("cannot decode Endpoints '%s': %w", name, err)
+	}
+	for _, subset := range legacy.Subsets {
+		if subset == nil || len(subset.Addresses) == 0 {
+			continue
+		}
+		endpoints.anyReady = true
+		for _, port := range subset.Ports {
+			if port != nil {
+				endpoints.readyPorts[port.Name] = true
+			}
+		}
+	}
+	return endpoints, nil
+}
@@ -0,0 +1,160 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+)
+
+// endpointFixtures 返回带有不同端点状态的 Service 及其 EndpointSlice/Endpoints。
+func endpointFixtures() mockResources {
+	twoPorts := func() *corev1.ServiceSpec {
+		return &corev1.ServiceSpec{
+			Type:     "ClusterIP",
+			Selector: map[string]string{"app": "demo"},
+			Ports: []*corev1.ServicePort{
+				{Name: "http", Port: int32Ptr(80), Protocol: "TCP"},
+				{Name: "admin", Port: int32Ptr(9000), Protocol: "TCP"},
+			},
+		}
+	}
+	// endpoint 构造 EndpointSlice 中的一个端点，ready 为 nil 时不写 conditions。
+	endpoint := func(address string, ready *bool) map[string]interface{} {
+		e := map[string]interface{}{"addresses": []string{address}}
+		if ready != nil {
+			e["conditions"] = map[string]interface{}{"ready": *ready}
+		}
+		return e
+	}
+	port := func(name string, number int32) map[string]interface{} {
+		return map[string]interface{}{"name": name, "port": number}
+	}
+	resources := mockResources{}
+	// slice 构造只包含一个端点的 EndpointSlice。
+	slice := func(name, service string, endpoint map[string]interface{}, ports ...map[string]interface{}) {
+		resources.addObject(endpointSliceAPIVersion, "EndpointSlice", &metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{endpointSliceServiceLabel: service},
+		}, map[string]interface{}{"addressType": "IPv4", "endpoints": []interface{}{endpoint}, "ports": ports})
+	}
+	ready, notReady := true, false
+
+	resources.addService("default", "ready-service", twoPorts())
+	slice("ready-service-abc", "ready-service", endpoint("10.0.0.1", &ready), port("http", 8080), port("admin", 9000))
+	resources.addService("default", "partial-service", twoPorts())
+	slice("partial-service-abc", "partial-service", endpoint("10.0.0.2", nil), port("http", 8080))
+	slice("partial-service-def", "partial-service", endpoint("10.0.0.3", &notReady), port("admin", 9000))
+	resources.addService("default", "legacy-service", twoPorts())
+	resources.add("Endpoints", "default", "legacy-service", &corev1.Endpoints{
+		APIVersion: "v1",
+		Kind:       "Endpoints",
+		Metadata:   &metav1.ObjectMeta{Name: "legacy-service", Namespace: "default"},
+		Subsets: []*corev1.EndpointSubset{{
+			Addresses: []*corev1.EndpointAddress{{IP: strPtr("10.0.0.4")}},
+			Ports:     []*corev1.EndpointPort{{Name: "http", Port: int32Ptr(8080)}},
+		}},
+	})
+	resources.addService("default", "idle-service", twoPorts())
+	resources.addService("default", "headless-service", &corev1.ServiceSpec{
+		ClusterIP: headlessClusterIP,
+		Selector:  map[string]string{"app": "db"},
+	})
+	slice("headless-service-abc", "headless-service", endpoint("10.0.0.5", nil))
+	resources.addService("default", "manual-headless", &corev1.ServiceSpec{
+		ClusterIP: headlessClusterIP,
+		Ports:     []*corev1.ServicePort{{Name: "http", Port: int32Ptr(80), Protocol: "TCP"}},
+	})
+	return resources
+}
+
+// endpointIngress 构造一个引用给定 Service 与端口的 Ingress。
+func endpointIngress(service string, port networkingv1.ServiceBackendPort) *networkingv1.Ingress {
+	backendPort := port
+	return &networkingv1.Ingress{
+		Metadata: &metav1.ObjectMeta{Name: "test-ingress", Namespace: "default"},
+		Spec: &networkingv1.IngressSpec{
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{Name: strPtr(service), Port: &backendPort},
+			},
+		},
+	}
+}
+
+func TestRequireReadyEndpoints(t *testing.T) {
+	tests := []struct {
+		name          string
+		service       string
+		port          networkingv1.ServiceBackendPort
+		expectAccept  bool
+		expectMessage string
+	}{
+		{name: "ready endpoint slice", service: "ready-service", port: networkingv1.ServiceBackendPort{Number: 9000}, expectAccept: true},
+		{name: "ready port by name", service: "partial-service", port: networkingv1.ServiceBackendPort{Name: "http"}, expectAccept: true},
+		{
+			name:          "port without ready endpoints",
+			service:       "partial-service",
+			port:          networkingv1.ServiceBackendPort{Number: 9000},
+			expectMessage: "Service 'partial-service' in namespace 'default' has no ready endpoints for port 9000 (referenced by defaultBackend)",
+		},
+		{name: "legacy endpoints fallback", service: "legacy-service", port: networkingv1.ServiceBackendPort{Name: "http"}, expectAccept: true},
+		{
+			name:          "legacy endpoints without the port",
+			service:       "legacy-service",
+			port:          networkingv1.ServiceBackendPort{Name: "admin"},
+			expectMessage: "has no ready endpoints for port 'admin'",
+		},
+		{
+			name:          "no endpoints at all",
+			service:       "idle-service",
+			port:          networkingv1.ServiceBackendPort{Number: 80},
+			expectMessage: "Service 'idle-service' in namespace 'default' has no ready endpoints for port 80",
+		},
+		{name: "headless service without ports", service: "headless-service", expectAccept: true},
+		{name: "headless service without selector is skipped", service: "manual-headless", port: networkingv1.ServiceBackendPort{Number: 80}, expectAccept: true},
+		{name: "external name service is skipped", service: "external-service", port: networkingv1.ServiceBackendPort{Number: 443}, expectAccept: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host.Client = newMockWapcClient(endpointFixtures())
+			settings := Settings{EnforceServiceExists: true, RequireReadyEndpoints: enforcementModeDeny}
+			response := validateWithClient(t, endpointIngress(tt.service, tt.port), &settings)
+			if response.Accepted != tt.expectAccept {
+				t.Fatalf("Expected accepted=%v, got %v (message: %v)", tt.expectAccept, response.Accepted, response.Message)
+			}
+			if tt.expectMessage != "" && !strings.Contains(*response.Message, tt.expectMessage) {
+				t.Errorf("Expected message to contain '%s', got '%s'", tt.expectMessage, *response.Message)
+			}
+		})
+	}
+}
+
+func TestRequireReadyEndpointsWarnOnlyLogs(t *testing.T) {
+	host.Client = newMockWapcClient(endpointFixtures())
+	settings := Settings{EnforceServiceExists: true, RequireReadyEndpoints: enforcementModeWarn}
+	response := validateWithClient(t, endpointIngress("idle-service", networkingv1.ServiceBackendPort{Number: 80}), &settings)
+	if !response.Accepted {
+		t.Errorf("Expected acceptance in warn mode, got: %s", *response.Message)
+	}
+}
+
+func TestEndpointSliceListForbiddenFallsBackToEndpoints(t *testing.T) {
+	client := newMockWapcClient(endpointFixtures())
+	client.errors["EndpointSlice"] = "endpointslices.discovery.k8s.io is forbidden: User cannot list resource"
+	host.Client = client
+
+	settings := Settings{EnforceServiceExists: true, RequireReadyEndpoints: enforcementModeDeny}
+	response := validateWithClient(t, endpointIngress("legacy-service", networkingv1.ServiceBackendPort{Number: 80}), &settings)
+	if !response.Accepted {
+		t.Errorf("Expected fallback to Endpoints, got: %s", *response.Message)
+	}
+	// 回退后 ready-service 没有旧的 Endpoints 资源，视为没有就绪端点
+	response = validateWithClient(t, endpointIngress("ready-service", networkingv1.ServiceBackendPort{Number: 80}), &settings)
+	if response.Accepted {
+		t.Error("Expected rejection when neither EndpointSlices nor Endpoints report ready addresses")
+	}
+}
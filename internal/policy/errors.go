@@ -0,0 +1,110 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// host call 失败的分类，调用方通过 errors.Is 判断，而不是自行匹配错误字符串。
+var (
+	// ErrResourceNotFound 表示请求的资源不存在。
+	ErrResourceNotFound = errors.New("resource not found")
+	// ErrForbidden 表示策略无权访问该资源（RBAC 拒绝或未声明 contextAwareResources）。
+	ErrForbidden = errors.New("access forbidden")
+	// ErrTransient 表示可重试的基础设施错误，例如超时或 API Server 不可达。
+	ErrTransient = errors.New("transient error")
+	// ErrHostCallFailed 表示无法归类的 host call 失败。
+	ErrHostCallFailed = errors.New("host call failed")
+)
+
+// hostErrorPatterns 列出 Kubewarden host（policy-server 与 kwctl）以及 Kubernetes API Server 返回的错误措辞，按顺序匹配。
+// waPC 只把 host call 的错误作为字符串传给策略，无法取得状态码或错误类型，因此这里是唯一允许匹配错误字符串的地方，
+// 其他代码只依赖上面的哨兵错误。每条措辞都对应 host 的一种固定输出，例如 API Server 的
+// `services "x" is forbidden: User ...`、kube-rs 的 `reason: "NotFound"` 或 kwctl 的 `Cannot find v1/Service named ...`，
+// 不使用 "unavailable"、"not allowed" 这类可能出现在任意错误中的宽泛词语。
+// forbidden 排在 not found 之前：RBAC 拒绝的信息中可能包含带有 "not found" 字样的资源名，不能被当作资源不存在。
+//
+//nolint:gochecknoglobals // 只读的分类表
+var hostErrorPatterns = []struct {
+	kind error
+	// prefixes 匹配错误信息的开头。
+	prefixes []string
+	// phrases 按子串匹配。
+	phrases []string
+	// words 按完整单词匹配，用于容易误匹配的短词，例如 eof 不应匹配 geofence。
+	words []string
+}{
+	{kind: ErrForbidden, phrases: []string{
+		" is forbidden: user ", "has not been granted access to", `reason: "forbidden"`, `reason: "unauthorized"`,
+	}},
+	{kind: ErrResourceNotFound, prefixes: []string{"cannot find "}, phrases: []string{
+		`" not found`, `reason: "notfound"`,
+	}},
+	{kind: ErrTransient, phrases: []string{
+		"timed out", "deadline has elapsed", "deadline exceeded", "connection refused", "connection reset",
+		"the server is currently unable to handle the request",
+		`reason: "timeout"`, `reason: "servertimeout"`, `reason: "serviceunavailable"`, `reason: "toomanyrequests"`,
+	}, words: []string{"eof"}},
+}
+
+// hostCallError 包装一次 host call 失败，并记录其分类。
+type hostCallError struct {
+	kind error
+	err  error
+}
+
+func (e *hostCallError) Error() string {
+	if e.kind == ErrHostCallFailed {
+		return fmt.Sprintf("%s: %s", ErrHostCallFailed, e.err)
+	}
+	return fmt.Sprintf("%s (%s): %s", ErrHostCallFailed, e.kind, e.err)
+}
+
+// Is 让 errors.Is 能够同时匹配分类哨兵与 ErrHostCallFailed。
+func (e *hostCallError) Is(target error) bool {
+	return target == e.kind || target == ErrHostCallFailed
+}
+
+func (e *hostCallError) Unwrap() error {
+	return e.err
+}
+
+// classifyHostError 将 host call 返回的原始错误归类为 hostCallError。
+func classifyHostError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var classified *hostCallError
+	if errors.As(err, &classified) {
+		return err
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return &hostCallError{kind: ErrTransient, err: err}
+	}
+	msg := strings.ToLower(err.Error())
+	words := strings.FieldsFunc(msg, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, class := range hostErrorPatterns {
+		for _, prefix := range class.prefixes {
+			if strings.HasPrefix(msg, prefix) {
+				return &hostCallError{kind: class.kind, err: err}
+			}
+		}
+		for _, phrase := range class.phrases {
+			if strings.Contains(msg, phrase) {
+				return &hostCallError{kind: class.kind, err: err}
+			}
+		}
+		for _, word := range class.words {
+			if slices.Contains(words, word) {
+				return &hostCallError{kind: class.kind, err: err}
+			}
+		}
+	}
+	return &hostCallError{kind: ErrHostCallFailed, err: err}
+}
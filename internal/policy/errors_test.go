@@ -0,0 +1,93 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestClassifyHostError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"kwctl not found", errors.New("Cannot find v1/Service named 'x' inside of namespace 'Some(\"default\")'"), ErrResourceNotFound},
+		{"api not found", errors.New(`services "x" not found`), ErrResourceNotFound},
+		{"rbac denial", errors.New(`services "x" is forbidden: User "system:serviceaccount:kubewarden:policy-server" cannot get resource`), ErrForbidden},
+		{"context aware not granted", errors.New("Policy has not been granted access to Kubernetes v1/Secret resources"), ErrForbidden},
+		{"timeout", errors.New("request timed out after 5s"), ErrTransient},
+		{"unreachable", errors.New("dial tcp 10.96.0.1:443: connect: connection refused"), ErrTransient},
+		{"rbac denial mentioning not found", errors.New(`secrets "cert-not-found" is forbidden: User "policy-server" cannot get resource`), ErrForbidden},
+		{"truncated response", errors.New("cannot read response: unexpected EOF"), ErrTransient},
+		{"wrapped io.EOF", fmt.Errorf("reading host response: %w", io.EOF), ErrTransient},
+		{"eof inside a word", errors.New("cannot decode field 'geofence'"), ErrHostCallFailed},
+		{"api server unauthorized", errors.New(`ApiError: Unauthorized: Unauthorized (ErrorResponse { status: "Failure", ` +
+			`message: "Unauthorized", reason: "Unauthorized", code: 401 })`), ErrForbidden},
+		{"api server not found reason", errors.New(`ApiError: NotFound (ErrorResponse { reason: "NotFound", code: 404 })`), ErrResourceNotFound},
+		{"api server unavailable", errors.New(`ApiError: the server is currently unable to handle the request ` +
+			`(ErrorResponse { reason: "ServiceUnavailable", code: 503 })`), ErrTransient},
+		{"deadline elapsed", errors.New("deadline has elapsed"), ErrTransient},
+		{"unrelated not allowed", errors.New("field 'spec.port' is not allowed to be empty"), ErrHostCallFailed},
+		{"unrelated unavailable", errors.New("feature unavailable in this host version"), ErrHostCallFailed},
+		{"unrelated timeout", errors.New("invalid timeout value in request"), ErrHostCallFailed},
+		{"unknown", errors.New("something unexpected"), ErrHostCallFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classified := classifyHostError(tt.err)
+			if !errors.Is(classified, tt.expected) {
+				t.Errorf("Expected %q to be classified as %v, got %v", tt.err, tt.expected, classified)
+			}
+			if !errors.Is(classified, ErrHostCallFailed) {
+				t.Errorf("Expected every classified error to match ErrHostCallFailed")
+			}
+			if !errors.Is(classified, tt.err) {
+				t.Errorf("Expected the original error to remain in the chain")
+			}
+		})
+	}
+
+	if classifyHostError(nil) != nil {
+		t.Error("Expected nil error to stay nil")
+	}
+}
+
+func TestFailurePolicy(t *testing.T) {
+	ingress := missingServiceIngress("default", nil, nil)
+	ingress.Spec.DefaultBackend.Service.Name = strPtr("forbidden-service")
+
+	// 默认 fail：拒绝并说明原因
+	settings := Settings{EnforceServiceExists: true}
+	response := runValidation(t, &ingress, &settings)
+	if response.Accepted {
+		t.Fatal("Expected failure_policy=fail to reject on forbidden host call")
+	}
+	expectedMessage := "Errors checking referenced objects: Service 'forbidden-service' (referenced by defaultBackend): " +
+		`host call failed (access forbidden): services "forbidden-service" is forbidden: ` +
+		`User "policy-server" cannot get resource "services" (failure_policy=fail: request rejected)`
+	if response.Message == nil {
+		t.Fatal("Expected response to have a message")
+	}
+	if *response.Message != expectedMessage {
+		t.Errorf("Got '%s' instead of '%s'", *response.Message, expectedMessage)
+	}
+
+	// ignore：放行，但在响应中记录
+	settings.FailurePolicy = failurePolicyIgnore
+	response = runValidation(t, &ingress, &settings)
+	if !response.Accepted {
+		t.Fatalf("Expected failure_policy=ignore to accept, got: %s", *response.Message)
+	}
+	if response.Message == nil {
+		t.Fatal("Expected the accepted response to record the failure policy")
+	}
+
+	// ignore 不影响确定性的校验失败
+	ingress.Spec.DefaultBackend.Service.Name = strPtr("non-existent-service")
+	if response = runValidation(t, &ingress, &settings); response.Accepted {
+		t.Error("Expected missing Service to be rejected even with failure_policy=ignore")
+	}
+}
@@ -0,0 +1,148 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+)
+
+// ingressOptOutKey 是 Ingress 自行豁免时使用的标签/注解键，值为 "true" 时生效。
+const ingressOptOutKey = "deny-ingress-no-service.kubewarden.io/skip"
+
+const (
+	selectorOpIn           = "In"
+	selectorOpNotIn        = "NotIn"
+	selectorOpExists       = "Exists"
+	selectorOpDoesNotExist = "DoesNotExist"
+)
+
+// exemptionReason 判断被校验的对象是否被豁免，返回豁免原因；未豁免时返回空字符串。
+// 检查顺序为：命名空间列表、对象标签/注解、命名空间标签选择器（需要 host call）。
+func exemptionReason(meta *metav1.ObjectMeta, settings Settings) (string, error) {
+	if meta == nil {
+		return "", nil
+	}
+	namespace := meta.Namespace
+
+	if slices.Contains(settings.ExemptNamespaces, namespace) {
+		return fmt.Sprintf("namespace '%s' is listed in exempt_namespaces", namespace), nil
+	}
+
+	if settings.AllowIngressOptOut {
+		if meta.Labels[ingressOptOutKey] == "true" {
+			return fmt.Sprintf("object carries the '%s' label", ingressOptOutKey), nil
+		}
+		if meta.Annotations[ingressOptOutKey] == "true" {
+			return fmt.Sprintf("object carries the '%s' annotation", ingressOptOutKey), nil
+		}
+	}
+
+	if settings.ExemptNamespaceSelector != nil {
+		ns, err := getNamespace(namespace, settings)
+		if err != nil {
+			return "", err
+		}
+		var labels map[string]string
+		if ns.Metadata != nil {
+			labels = ns.Metadata.Labels
+		}
+		if labelSelectorMatches(settings.ExemptNamespaceSelector, labels) {
+			return fmt.Sprintf("namespace '%s' matches exempt_namespace_selector", namespace), nil
+		}
+	}
+
+	return "", nil
+}
+
+// getNamespace 通过 host capabilities 获取 Namespace 对象。
+func getNamespace(name string, settings Settings) (*corev1.Namespace, error) {
+	respBytes, err := getResource(getResourceRequest{
+		APIVersion:   "v1",
+		Kind:         "Namespace",
+		Name:         name,
+		DisableCache: settings.DisableCache,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot get Namespace '%s': %w", name, err)
+	}
+	ns := &corev1.Namespace{}
+	if err := json.Unmarshal(respBytes, ns); err != nil {
+		return nil, fmt.Errorf("cannot decode Namespace '%s': %w", name, err)
+	}
+	return ns, nil
+}
+
+// labelSelectorMatches 按 Kubernetes LabelSelector 语义判断 labels 是否命中选择器。
+// 调用前选择器应已通过 validateLabelSelector 校验。
+func labelSelectorMatches(selector *metav1.LabelSelector, labels map[string]string) bool {
+	for key, value := range selector.MatchLabels {
+		if actual, ok := labels[key]; !ok || actual != value {
+			return false
+		}
+	}
+	for _, req := range selector.MatchExpressions {
+		if req == nil || req.Key == nil || req.Operator == nil {
+			return false
+		}
+		actual, ok := labels[*req.Key]
+		switch *req.Operator {
+		case selectorOpIn:
+			if !ok || !slices.Contains(req.Values, actual) {
+				return false
+			}
+		case selectorOpNotIn:
+			if ok && slices.Contains(req.Values, actual) {
+				return false
+			}
+		case selectorOpExists:
+			if !ok {
+				return false
+			}
+		case selectorOpDoesNotExist:
+			if ok {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// validateLabelSelector 校验标签选择器的结构是否合法。
+// 空选择器会匹配所有命名空间，因此同样视为非法配置。
+func validateLabelSelector(selector *metav1.LabelSelector) error {
+	if len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0 {
+		return errors.New("selector must define matchLabels or matchExpressions")
+	}
+	for key := range selector.MatchLabels {
+		if key == "" {
+			return errors.New("matchLabels contains an empty key")
+		}
+	}
+	for i, req := range selector.MatchExpressions {
+		if req == nil || req.Key == nil || *req.Key == "" {
+			return fmt.Errorf("matchExpressions[%d]: key is required", i)
+		}
+		if req.Operator == nil {
+			return fmt.Errorf("matchExpressions[%d]: operator is required", i)
+		}
+		switch *req.Operator {
+		case selectorOpIn, selectorOpNotIn:
+			if len(req.Values) == 0 {
+				return fmt.Errorf("matchExpressions[%d]: operator %s requires at least one value", i, *req.Operator)
+			}
+		case selectorOpExists, selectorOpDoesNotExist:
+			if len(req.Values) > 0 {
+				return fmt.Errorf("matchExpressions[%d]: operator %s does not accept values", i, *req.Operator)
+			}
+		default:
+			return fmt.Errorf("matchExpressions[%d]: unknown operator '%s'", i, *req.Operator)
+		}
+	}
+	return nil
+}
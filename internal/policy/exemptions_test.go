@@ -0,0 +1,114 @@
+package policy
+
+import (
+	"testing"
+
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+)
+
+// missingServiceIngress 构造一个引用不存在 Service 的 Ingress。
+func missingServiceIngress(namespace string, labels, annotations map[string]string) networkingv1.Ingress {
+	return networkingv1.Ingress{
+		Metadata: &metav1.ObjectMeta{
+			Name:        "exempt-ingress",
+			Namespace:   namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: &networkingv1.IngressSpec{
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: strPtr("non-existent-service"),
+					Port: &networkingv1.ServiceBackendPort{Number: 80},
+				},
+			},
+		},
+	}
+}
+
+func TestExemptNamespacesSkipValidation(t *testing.T) {
+	ingress := missingServiceIngress("sandbox", nil, nil)
+	settings := Settings{
+		EnforceServiceExists: true,
+		ExemptNamespaces:     []string{"kube-system", "sandbox"},
+	}
+	if response := runValidation(t, &ingress, &settings); !response.Accepted {
+		t.Errorf("Expected exempt namespace to be accepted, got: %s", *response.Message)
+	}
+
+	ingress = missingServiceIngress("default", nil, nil)
+	if response := runValidation(t, &ingress, &settings); response.Accepted {
+		t.Error("Expected non-exempt namespace to be rejected")
+	}
+}
+
+func TestNamespaceSelectorExemption(t *testing.T) {
+	settings := Settings{
+		EnforceServiceExists: true,
+		ExemptNamespaceSelector: &metav1.LabelSelector{
+			MatchExpressions: []*metav1.LabelSelectorRequirement{
+				{Key: strPtr("env"), Operator: strPtr("In"), Values: []string{"dev", "test"}},
+			},
+		},
+	}
+
+	ingress := missingServiceIngress("sandbox", nil, nil)
+	if response := runValidation(t, &ingress, &settings); !response.Accepted {
+		t.Errorf("Expected namespace matching the selector to be accepted, got: %s", *response.Message)
+	}
+
+	ingress = missingServiceIngress("default", nil, nil)
+	if response := runValidation(t, &ingress, &settings); response.Accepted {
+		t.Error("Expected namespace not matching the selector to be rejected")
+	}
+}
+
+func TestIngressOptOut(t *testing.T) {
+	annotations := map[string]string{ingressOptOutKey: "true"}
+
+	// 未开启 allow_ingress_opt_out 时注解无效
+	ingress := missingServiceIngress("default", nil, annotations)
+	settings := Settings{EnforceServiceExists: true}
+	if response := runValidation(t, &ingress, &settings); response.Accepted {
+		t.Error("Expected opt-out annotation to be ignored when allow_ingress_opt_out is false")
+	}
+
+	settings.AllowIngressOptOut = true
+	if response := runValidation(t, &ingress, &settings); !response.Accepted {
+		t.Errorf("Expected opt-out annotation to be honoured, got: %s", *response.Message)
+	}
+
+	ingress = missingServiceIngress("default", map[string]string{ingressOptOutKey: "true"}, nil)
+	if response := runValidation(t, &ingress, &settings); !response.Accepted {
+		t.Errorf("Expected opt-out label to be honoured, got: %s", *response.Message)
+	}
+}
+
+func TestLabelSelectorMatches(t *testing.T) {
+	labels := map[string]string{"env": "dev", "team": "a"}
+	tests := []struct {
+		name     string
+		selector metav1.LabelSelector
+		expected bool
+	}{
+		{"matchLabels hit", metav1.LabelSelector{MatchLabels: map[string]string{"env": "dev"}}, true},
+		{"matchLabels miss", metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}, false},
+		{"NotIn", metav1.LabelSelector{MatchExpressions: []*metav1.LabelSelectorRequirement{
+			{Key: strPtr("env"), Operator: strPtr("NotIn"), Values: []string{"prod"}},
+		}}, true},
+		{"Exists", metav1.LabelSelector{MatchExpressions: []*metav1.LabelSelectorRequirement{
+			{Key: strPtr("team"), Operator: strPtr("Exists")},
+		}}, true},
+		{"DoesNotExist", metav1.LabelSelector{MatchExpressions: []*metav1.LabelSelectorRequirement{
+			{Key: strPtr("team"), Operator: strPtr("DoesNotExist")},
+		}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := labelSelectorMatches(&tt.selector, labels); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
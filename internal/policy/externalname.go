@@ -0,0 +1,156 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"path"
+	"strings"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+)
+
+// defaultDeniedExternalNameDestinations 是始终禁止的 ExternalName 目标：
+// 集群内部的 Service 域名（包括自定义集群域名下的 <service>.<namespace>.svc.<domain>）、
+// 私有与共享地址段（Pod 与 Service CIDR 通常位于其中）、本机回环、链路本地地址以及云厂商的元数据服务。
+// 需要例外时通过 allowed_external_name_destinations 显式放行。
+//
+//nolint:gochecknoglobals // 只读的查找表
+var defaultDeniedExternalNameDestinations = []string{
+	"localhost",
+	"*.localhost",
+	"kubernetes",
+	"kubernetes.default",
+	"*.svc",
+	"*.svc.cluster.local",
+	"*.*.svc.*",
+	"metadata",
+	"metadata.google.internal",
+	"instance-data",
+	"instance-data.ec2.internal",
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"100.100.100.200/32",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+	"fd00:ec2::254/128",
+}
+
+// destinationPatterns 是解析后的目标列表，主机名使用 glob，IP 字面量与 CIDR 使用前缀匹配。
+type destinationPatterns struct {
+	hosts    []string
+	prefixes []netip.Prefix
+}
+
+// parseDestinationPatterns 解析目标列表。每一项可以是主机名 glob（path.Match 语义）、
+// IP 地址或 CIDR。
+func parseDestinationPatterns(entries []string) (destinationPatterns, error) {
+	var patterns destinationPatterns
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			return destinationPatterns{}, errors.New("empty destination")
+		}
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			patterns.prefixes = append(patterns.prefixes, prefix.Masked())
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			patterns.prefixes = append(patterns.prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		pattern := normalizeHost(entry)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return destinationPatterns{}, fmt.Errorf("'%s': %w", entry, err)
+		}
+		patterns.hosts = append(patterns.hosts, pattern)
+	}
+	return patterns, nil
+}
+
+// match 返回匹配目标的第一项，未匹配时返回空字符串。
+// IP 字面量只与 IP/CIDR 比较，主机名只与 glob 比较。
+func (p destinationPatterns) match(destination string) string {
+	destination = normalizeHost(destination)
+	if addr, err := netip.ParseAddr(strings.Trim(destination, "[]")); err == nil {
+		addr = addr.Unmap()
+		for _, prefix := range p.prefixes {
+			if prefix.Contains(addr) {
+				return prefix.String()
+			}
+		}
+		return ""
+	}
+	for _, pattern := range p.hosts {
+		if matched, _ := path.Match(pattern, destination); matched {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// validateExternalNameDestinationSettings 校验 ExternalName 目标相关设置中的每一项都可以解析。
+func validateExternalNameDestinationSettings(s *Settings) error {
+	if _, err := parseDestinationPatterns(s.DeniedExternalNameDestinations); err != nil {
+		return fmt.Errorf("invalid denied_external_name_destinations entry %w", err)
+	}
+	if _, err := parseDestinationPatterns(s.AllowedExternalNameDestinations); err != nil {
+		return fmt.Errorf("invalid allowed_external_name_destinations entry %w", err)
+	}
+	return nil
+}
+
+// checkExternalNameDestination 校验 ExternalName Service 的 spec.externalName。
+// 命中 allowed_external_name_destinations 的目标总是放行；否则命中内置禁止列表或
+// denied_external_name_destinations 时拒绝。allowed_external_name_destinations
+// 非空时，未命中其中任何一项的目标同样被拒绝。
+func checkExternalNameDestination(settings Settings, service *corev1.Service, refs []serviceReference, report *validationReport) {
+	if service == nil || service.Spec == nil || service.Spec.Type != "ExternalName" {
+		return
+	}
+	destination := strings.TrimSpace(service.Spec.ExternalName)
+	if destination == "" {
+		return
+	}
+	reason := externalNameDenialReason(settings, destination)
+	if reason == "" {
+		return
+	}
+	namespace, name := "", ""
+	if service.Metadata != nil {
+		namespace, name = service.Metadata.Namespace, service.Metadata.Name
+	}
+	if namespace == "" && len(refs) > 0 {
+		namespace, name = refs[0].Namespace, refs[0].Name
+	}
+	report.addViolation("ExternalName Service '%s' in namespace '%s' points at forbidden destination '%s' (%s) (referenced by %s)",
+		name, namespace, destination, reason, formatReferenceLocations(refs))
+}
+
+// externalNameDenialReason 返回目标被拒绝的原因，允许时返回空字符串。
+// 设置已在 Valid 中校验过，这里忽略解析错误。
+func externalNameDenialReason(settings Settings, destination string) string {
+	allowed, _ := parseDestinationPatterns(settings.AllowedExternalNameDestinations)
+	if allowed.match(destination) != "" {
+		return ""
+	}
+	defaults, _ := parseDestinationPatterns(defaultDeniedExternalNameDestinations)
+	if entry := defaults.match(destination); entry != "" {
+		return fmt.Sprintf("matches default denied destination '%s'", entry)
+	}
+	denied, _ := parseDestinationPatterns(settings.DeniedExternalNameDestinations)
+	if entry := denied.match(destination); entry != "" {
+		return fmt.Sprintf("matches denied_external_name_destinations entry '%s'", entry)
+	}
+	if len(settings.AllowedExternalNameDestinations) > 0 {
+		return "not in allowed_external_name_destinations"
+	}
+	return ""
+}
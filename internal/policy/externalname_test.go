@@ -0,0 +1,126 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+// externalNameService 构造指向 destination 的 ExternalName Service 模拟资源。
+func externalNameService(name, destination string) map[string]string {
+	return map[string]string{
+		mockResourceKey("Service", "default", name): `{"kind":"Service","apiVersion":"v1","metadata":{"name":"` + name + `","namespace":"default"},` +
+			`"spec":{"type":"ExternalName","externalName":"` + destination + `"}}`,
+	}
+}
+
+func TestExternalNameDestinations(t *testing.T) {
+	tests := []struct {
+		name          string
+		destination   string
+		settings      Settings
+		expectMessage string
+	}{
+		{
+			name:        "public hostname is allowed",
+			destination: "api.example.com",
+		},
+		{
+			name:          "kubernetes API service is denied by default",
+			destination:   "kubernetes.default.svc",
+			expectMessage: "points at forbidden destination 'kubernetes.default.svc' (matches default denied destination '*.svc')",
+		},
+		{
+			name:          "fully qualified cluster name with trailing dot",
+			destination:   "db.other.svc.cluster.local.",
+			expectMessage: "matches default denied destination '*.svc.cluster.local'",
+		},
+		{
+			name:          "cloud metadata hostname",
+			destination:   "Metadata.Google.Internal",
+			expectMessage: "matches default denied destination 'metadata.google.internal'",
+		},
+		{
+			name:          "link-local metadata address",
+			destination:   "169.254.169.254",
+			expectMessage: "matches default denied destination '169.254.0.0/16'",
+		},
+		{
+			name:          "loopback IPv6 address",
+			destination:   "::1",
+			expectMessage: "matches default denied destination '::1/128'",
+		},
+		{
+			name:          "private IPv4 address",
+			destination:   "10.96.0.1",
+			expectMessage: "matches default denied destination '10.0.0.0/8'",
+		},
+		{
+			name:          "unique local IPv6 address",
+			destination:   "fd12:3456::10",
+			expectMessage: "matches default denied destination 'fc00::/7'",
+		},
+		{
+			name:          "service name under a custom cluster domain",
+			destination:   "db.prod.svc.corp.example",
+			expectMessage: "matches default denied destination '*.*.svc.*'",
+		},
+		{
+			name:          "custom denied CIDR",
+			destination:   "203.0.113.12",
+			settings:      Settings{DeniedExternalNameDestinations: []string{"203.0.113.0/24"}},
+			expectMessage: "matches denied_external_name_destinations entry '203.0.113.0/24'",
+		},
+		{
+			name:          "custom denied hostname glob",
+			destination:   "db.corp.internal",
+			settings:      Settings{DeniedExternalNameDestinations: []string{"*.corp.internal"}},
+			expectMessage: "matches denied_external_name_destinations entry '*.corp.internal'",
+		},
+		{
+			name:        "allow list overrides the default deny list",
+			destination: "shared.platform.svc.cluster.local",
+			settings:    Settings{AllowedExternalNameDestinations: []string{"*.platform.svc.cluster.local"}},
+		},
+		{
+			name:          "allow list rejects unlisted destinations",
+			destination:   "api.example.org",
+			settings:      Settings{AllowedExternalNameDestinations: []string{"*.example.com"}},
+			expectMessage: "points at forbidden destination 'api.example.org' (not in allowed_external_name_destinations)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host.Client = newMockWapcClient(externalNameService("proxy-service", tt.destination))
+			settings := tt.settings
+			settings.EnforceServiceExists = true
+			response := validateWithClient(t, pathIngress("proxy-service"), &settings)
+			if tt.expectMessage == "" {
+				if !response.Accepted {
+					t.Errorf("Unexpected rejection: %s", *response.Message)
+				}
+				return
+			}
+			if response.Accepted {
+				t.Fatal("Expected rejection for a forbidden ExternalName destination")
+			}
+			if !strings.Contains(*response.Message, tt.expectMessage) {
+				t.Errorf("Expected message to contain '%s', got '%s'", tt.expectMessage, *response.Message)
+			}
+			if !strings.Contains(*response.Message, "ExternalName Service 'proxy-service' in namespace 'default'") {
+				t.Errorf("Expected message to name the Service, got '%s'", *response.Message)
+			}
+		})
+	}
+}
+
+func TestInvalidExternalNameDestinationSettings(t *testing.T) {
+	for _, settings := range []Settings{
+		{DeniedExternalNameDestinations: []string{"[a-.example.com"}},
+		{AllowedExternalNameDestinations: []string{""}},
+	} {
+		if valid, err := settings.Valid(); valid || err == nil {
+			t.Errorf("Expected settings %+v to be rejected", settings)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	onelog "github.com/francoispqt/onelog"
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+	kubewarden "github.com/kubewarden/policy-sdk-go"
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+const (
+	gatewayAPIGroup = "gateway.networking.k8s.io"
+	httpRouteKind   = "HTTPRoute"
+	grpcRouteKind   = "GRPCRoute"
+	// referenceGrantAPIVersion 是 ReferenceGrant 目前的稳定版本。
+	referenceGrantAPIVersion = "gateway.networking.k8s.io/v1beta1"
+	serviceKind              = "Service"
+)
+
+// gatewayRoute 是 HTTPRoute 与 GRPCRoute 共有的字段子集，
+// k8s-objects 没有提供 Gateway API 类型，这里只声明校验需要的部分。
+type gatewayRoute struct {
+	APIVersion string             `json:"apiVersion,omitempty"`
+	Kind       string             `json:"kind,omitempty"`
+	Metadata   *metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec       *gatewayRouteSpec  `json:"spec,omitempty"`
+}
+
+type gatewayRouteSpec struct {
+	Rules []*gatewayRouteRule `json:"rules,omitempty"`
+}
+
+type gatewayRouteRule struct {
+	BackendRefs []*gatewayBackendRef  `json:"backendRefs,omitempty"`
+	Filters     []*gatewayRouteFilter `json:"filters,omitempty"`
+}
+
+type gatewayRouteFilter struct {
+	RequestMirror *gatewayRequestMirror `json:"requestMirror,omitempty"`
+}
+
+type gatewayRequestMirror struct {
+	BackendRef *gatewayBackendRef `json:"backendRef,omitempty"`
+}
+
+// gatewayBackendRef 对应 Gateway API 的 BackendObjectReference。
+type gatewayBackendRef struct {
+	Group     *string `json:"group,omitempty"`
+	Kind      *string `json:"kind,omitempty"`
+	Name      string  `json:"name"`
+	Namespace *string `json:"namespace,omitempty"`
+	Port      *int32  `json:"port,omitempty"`
+}
+
+// referenceGrantList 是 ReferenceGrant 列表中校验需要的字段子集。
+type referenceGrantList struct {
+	Items []*referenceGrant `json:"items"`
+}
+
+type referenceGrant struct {
+	Metadata *metav1.ObjectMeta  `json:"metadata,omitempty"`
+	Spec     *referenceGrantSpec `json:"spec,omitempty"`
+}
+
+type referenceGrantSpec struct {
+	From []*referenceGrantFrom `json:"from,omitempty"`
+	To   []*referenceGrantTo   `json:"to,omitempty"`
+}
+
+type referenceGrantFrom struct {
+	Group     string `json:"group"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+}
+
+type referenceGrantTo struct {
+	Group string  `json:"group"`
+	Kind  string  `json:"kind"`
+	Name  *string `json:"name,omitempty"`
+}
+
+// validateGatewayRoute 校验 Gateway API 的 HTTPRoute 与 GRPCRoute。
+func validateGatewayRoute(validationRequest *kubewarden_protocol.ValidationRequest, settings Settings) ([]byte, error) {
+	kind := validationRequest.Request.Kind.Kind
+	route, err := getGatewayRoute(validationRequest.Request.Object)
+	if err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(fmt.Sprintf("Cannot decode %s: %s", kind, err)),
+			kubewarden.Code(httpBadRequestStatusCode))
+	}
+
+	logger.DebugWithFields("validating gateway route", func(e onelog.Entry) {
+		e.String("kind", kind)
+		e.String("name", route.Metadata.Name)
+		e.String("namespace", route.Metadata.Namespace)
+	})
+
+	if !settings.IsEnforcementEnabled() {
+		return kubewarden.AcceptRequest()
+	}
+	if exempt, response, err := handleExemptions(route.Metadata, settings); exempt {
+		return response, err
+	}
+
+	report := newValidationReport()
+	preExisting := newValidationReport()
+	lookup := newServiceLookup(settings)
+	oldRoute := decodeOldObject(settings, validationRequest, getGatewayRoute)
+	checkChangedSeparately(extractRouteServiceReferences(route), extractRouteServiceReferences(oldRoute),
+		serviceReferenceIdentity, report, preExisting,
+		func(subset []serviceReference, r *validationReport) {
+			permitted := checkCrossNamespaceReferences(kind, route.Metadata.Namespace, subset, r)
+			checkServiceReferences(settings, lookup, permitted, r)
+		})
+	report.addPreExistingReport(preExisting)
+	return respondWithReport(settings, report, validationRequest.Request.Uid, route.Metadata)
+}
+
+// getGatewayRoute 从 RAW JSON 中解析出 HTTPRoute/GRPCRoute 对象。
+func getGatewayRoute(rawJSON json.RawMessage) (*gatewayRoute, error) {
+	if len(rawJSON) == 0 {
+		return nil, errors.New("empty route object")
+	}
+	route := &gatewayRoute{}
+	if err := json.Unmarshal(rawJSON, route); err != nil {
+		return nil, err
+	}
+	if route.Metadata == nil {
+		return nil, errors.New("route metadata is missing")
+	}
+	return route, nil
+}
+
+// extractRouteServiceReferences 按出现顺序收集路由中所有指向 core Service 的 backendRefs，
+// 包括 RequestMirror 过滤器中的 backendRef。其他类型的后端不在本策略的校验范围内。
+func extractRouteServiceReferences(route *gatewayRoute) []serviceReference {
+	if route == nil || route.Spec == nil {
+		return nil
+	}
+	var refs []serviceReference
+	add := func(ref *gatewayBackendRef, location string) {
+		if ref == nil || ref.Name == "" || !isServiceBackendRef(ref) {
+			return
+		}
+		namespace := route.Metadata.Namespace
+		if ref.Namespace != nil && *ref.Namespace != "" {
+			namespace = *ref.Namespace
+		}
+		var port *networkingv1.ServiceBackendPort
+		if ref.Port != nil {
+			port = &networkingv1.ServiceBackendPort{Number: *ref.Port}
+		}
+		refs = append(refs, serviceReference{
+			Namespace: namespace,
+			Name:      ref.Name,
+			Port:      port,
+			Location:  location,
+		})
+	}
+
+	for i, rule := range route.Spec.Rules {
+		if rule == nil {
+			continue
+		}
+		for j, ref := range rule.BackendRefs {
+			add(ref, fmt.Sprintf("rules[%d].backendRefs[%d]", i, j))
+		}
+		for j, filter := range rule.Filters {
+			if filter != nil && filter.RequestMirror != nil {
+				add(filter.RequestMirror.BackendRef, fmt.Sprintf("rules[%d].filters[%d].requestMirror.backendRef", i, j))
+			}
+		}
+	}
+	return refs
+}
+
+// isServiceBackendRef 判断 backendRef 是否指向 core 组的 Service（group 与 kind 的默认值分别为 "" 与 Service）。
+func isServiceBackendRef(ref *gatewayBackendRef) bool {
+	group := ""
+	if ref.Group != nil {
+		group = *ref.Group
+	}
+	kind := serviceKind
+	if ref.Kind != nil {
+		kind = *ref.Kind
+	}
+	return (group == "" || group == "core") && kind == serviceKind
+}
+
+// checkCrossNamespaceReferences 检查跨命名空间的引用是否被目标命名空间中的 ReferenceGrant 允许，
+// 返回可以继续做存在性检查的引用。
+func checkCrossNamespaceReferences(
+	routeKind, routeNamespace string,
+	refs []serviceReference,
+	report *validationReport,
+) []serviceReference {
+	permitted := make([]serviceReference, 0, len(refs))
+	grants := make(map[string][]*referenceGrant)
+	for _, ref := range refs {
+		if ref.Namespace == routeNamespace {
+			permitted = append(permitted, ref)
+			continue
+		}
+		nsGrants, ok := grants[ref.Namespace]
+		if !ok {
+			var err error
+			nsGrants, err = listReferenceGrants(ref.Namespace)
+			if err != nil {
+				report.addHostError(fmt.Sprintf("ReferenceGrants in namespace '%s'", ref.Namespace), ref.Location, err)
+				continue
+			}
+			grants[ref.Namespace] = nsGrants
+		}
+		if !referenceGranted(nsGrants, routeKind, routeNamespace, ref.Name) {
+			report.addViolation("%s in namespace '%s' references Service '%s' in namespace '%s' "+
+				"without a matching ReferenceGrant (referenced by %s)",
+				routeKind, routeNamespace, ref.Name, ref.Namespace, ref.Location)
+			continue
+		}
+		permitted = append(permitted, ref)
+	}
+	return permitted
+}
+
+// referenceGranted 判断 ReferenceGrant 是否允许指定路由引用目标 Service。
+func referenceGranted(grants []*referenceGrant, routeKind, routeNamespace, serviceName string) bool {
+	for _, grant := range grants {
+		if grant == nil || grant.Spec == nil {
+			continue
+		}
+		fromOK := false
+		for _, from := range grant.Spec.From {
+			if from != nil && from.Group == gatewayAPIGroup && from.Kind == routeKind && from.Namespace == routeNamespace {
+				fromOK = true
+				break
+			}
+		}
+		if !fromOK {
+			continue
+		}
+		for _, to := range grant.Spec.To {
+			if to == nil || to.Group != "" || to.Kind != serviceKind {
+				continue
+			}
+			if to.Name == nil || *to.Name == "" || *to.Name == serviceName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// listReferenceGrants 列出命名空间中的全部 ReferenceGrant。
+func listReferenceGrants(namespace string) ([]*referenceGrant, error) {
+	respBytes, err := listResourcesByNamespace(listResourcesByNamespaceRequest{
+		APIVersion: referenceGrantAPIVersion,
+		Kind:       "ReferenceGrant",
+		Namespace:  namespace,
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := &referenceGrantList{}
+	if err := json.Unmarshal(respBytes, list); err != nil {
+		return nil, fmt.Errorf("cannot decode ReferenceGrant list: %w", err)
+	}
+	return list.Items, nil
+}
@@ -0,0 +1,98 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+)
+
+// int32Ptr 返回 int32 指针的辅助函数。
+func int32Ptr(v int32) *int32 {
+	return &v
+}
+
+// routeWithBackends 构造一个只有一条规则的路由，包含给定的 backendRefs。
+func routeWithBackends(kind string, refs ...*gatewayBackendRef) *gatewayRoute {
+	return &gatewayRoute{
+		APIVersion: "gateway.networking.k8s.io/v1",
+		Kind:       kind,
+		Metadata: &metav1.ObjectMeta{
+			Name:      "test-route",
+			Namespace: "default",
+		},
+		Spec: &gatewayRouteSpec{
+			Rules: []*gatewayRouteRule{{BackendRefs: refs}},
+		},
+	}
+}
+
+func TestHTTPRouteWithExistingBackendsIsAccepted(t *testing.T) {
+	setupTestEnv()
+	route := routeWithBackends(httpRouteKind,
+		&gatewayBackendRef{Name: "my-service", Port: int32Ptr(8080)},
+		&gatewayBackendRef{Name: "shared-service", Namespace: strPtr("shared"), Port: int32Ptr(80)},
+	)
+	settings := Settings{EnforceServiceExists: true}
+	if response := validateKindWithClient(t, gatewayAPIGroup, httpRouteKind, route, &settings); !response.Accepted {
+		t.Errorf("Unexpected rejection: %s", *response.Message)
+	}
+}
+
+func TestHTTPRouteMissingBackendsAreRejected(t *testing.T) {
+	setupTestEnv()
+	route := routeWithBackends(httpRouteKind,
+		&gatewayBackendRef{Name: "non-existent-service", Port: int32Ptr(80)},
+		&gatewayBackendRef{Name: "my-service", Port: int32Ptr(8443)},
+	)
+	route.Spec.Rules[0].Filters = []*gatewayRouteFilter{{
+		RequestMirror: &gatewayRequestMirror{BackendRef: &gatewayBackendRef{Name: "mirror-service"}},
+	}}
+	settings := Settings{EnforceServiceExists: true}
+	response := validateKindWithClient(t, gatewayAPIGroup, httpRouteKind, route, &settings)
+	if response.Accepted {
+		t.Fatal("Expected rejection for missing HTTPRoute backends")
+	}
+	for _, expected := range []string{
+		"Service 'non-existent-service' does not exist in namespace 'default' (referenced by rules[0].backendRefs[0])",
+		"does not expose port 8443 referenced by rules[0].backendRefs[1]",
+		"Service 'mirror-service' does not exist in namespace 'default' (referenced by rules[0].filters[0].requestMirror.backendRef)",
+	} {
+		if !strings.Contains(*response.Message, expected) {
+			t.Errorf("Expected message to contain '%s', got '%s'", expected, *response.Message)
+		}
+	}
+}
+
+func TestCrossNamespaceBackendRequiresReferenceGrant(t *testing.T) {
+	setupTestEnv()
+	route := routeWithBackends(grpcRouteKind,
+		&gatewayBackendRef{Name: "shared-service", Namespace: strPtr("shared"), Port: int32Ptr(80)},
+		&gatewayBackendRef{Name: "private-service", Namespace: strPtr("restricted"), Port: int32Ptr(80)},
+	)
+	settings := Settings{EnforceServiceExists: true}
+	response := validateKindWithClient(t, gatewayAPIGroup, grpcRouteKind, route, &settings)
+	if response.Accepted {
+		t.Fatal("Expected rejection without a matching ReferenceGrant")
+	}
+	// shared 中的 ReferenceGrant 只允许 HTTPRoute，因此 GRPCRoute 的两个引用都应被拒绝
+	for _, expected := range []string{
+		"GRPCRoute in namespace 'default' references Service 'shared-service' in namespace 'shared' without a matching ReferenceGrant",
+		"GRPCRoute in namespace 'default' references Service 'private-service' in namespace 'restricted' without a matching ReferenceGrant",
+	} {
+		if !strings.Contains(*response.Message, expected) {
+			t.Errorf("Expected message to contain '%s', got '%s'", expected, *response.Message)
+		}
+	}
+}
+
+func TestNonServiceBackendRefsAreIgnored(t *testing.T) {
+	setupTestEnv()
+	route := routeWithBackends(httpRouteKind,
+		&gatewayBackendRef{Group: strPtr("example.com"), Kind: strPtr("Bucket"), Name: "assets"},
+	)
+	settings := Settings{EnforceServiceExists: true}
+	if response := validateKindWithClient(t, gatewayAPIGroup, httpRouteKind, route, &settings); !response.Accepted {
+		t.Errorf("Unexpected rejection: %s", *response.Message)
+	}
+}
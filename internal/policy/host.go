@@ -0,0 +1,119 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+
+	onelog "github.com/francoispqt/onelog"
+)
+
+// getResourceRequest 对应 Kubewarden get_resource host capability 的请求参数。
+type getResourceRequest struct {
+	APIVersion string `json:"api_version"`
+	Kind       string `json:"kind"`
+	// Namespace 为空时表示集群级资源（例如 Namespace）。
+	Namespace    string `json:"namespace,omitempty"`
+	Name         string `json:"name"`
+	DisableCache bool   `json:"disable_cache"`
+}
+
+// getResource 通过 host capabilities 获取单个 Kubernetes 资源的原始 JSON。
+// 返回的错误已经过 classifyHostError 分类。
+func getResource(req getResourceRequest) ([]byte, error) {
+	//nolint:errcheck // Entry methods return self for chaining
+	logger.DebugWithFields("fetching resource", func(e onelog.Entry) {
+		e.String("api_version", req.APIVersion)
+		e.String("kind", req.Kind)
+		e.String("namespace", req.Namespace)
+		e.String("name", req.Name)
+		e.Bool("disable_cache", req.DisableCache)
+	})
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal get_resource request: %w", err)
+	}
+
+	respBytes, err := host.Client.HostCall(
+		"kubewarden",
+		"kubernetes",
+		"get_resource",
+		reqBytes,
+	)
+	if err != nil {
+		return nil, classifyHostError(err)
+	}
+	return respBytes, nil
+}
+
+// listAllResourcesRequest 对应 Kubewarden list_resources_all host capability 的请求参数。
+type listAllResourcesRequest struct {
+	APIVersion    string `json:"api_version"`
+	Kind          string `json:"kind"`
+	LabelSelector string `json:"label_selector,omitempty"`
+	FieldSelector string `json:"field_selector,omitempty"`
+}
+
+// listAllResources 通过 host capabilities 列出集群内某类资源，返回 List 对象的原始 JSON。
+// 返回的错误已经过 classifyHostError 分类。
+func listAllResources(req listAllResourcesRequest) ([]byte, error) {
+	//nolint:errcheck // Entry methods return self for chaining
+	logger.DebugWithFields("listing resources", func(e onelog.Entry) {
+		e.String("api_version", req.APIVersion)
+		e.String("kind", req.Kind)
+		e.String("label_selector", req.LabelSelector)
+	})
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal list_resources_all request: %w", err)
+	}
+
+	respBytes, err := host.Client.HostCall(
+		"kubewarden",
+		"kubernetes",
+		"list_resources_all",
+		reqBytes,
+	)
+	if err != nil {
+		return nil, classifyHostError(err)
+	}
+	return respBytes, nil
+}
+
+// listResourcesByNamespaceRequest 对应 Kubewarden list_resources_by_namespace host capability 的请求参数。
+type listResourcesByNamespaceRequest struct {
+	APIVersion    string `json:"api_version"`
+	Kind          string `json:"kind"`
+	Namespace     string `json:"namespace"`
+	LabelSelector string `json:"label_selector,omitempty"`
+	FieldSelector string `json:"field_selector,omitempty"`
+}
+
+// listResourcesByNamespace 通过 host capabilities 列出命名空间内某类资源，返回 List 对象的原始 JSON。
+// 返回的错误已经过 classifyHostError 分类。
+func listResourcesByNamespace(req listResourcesByNamespaceRequest) ([]byte, error) {
+	//nolint:errcheck // Entry methods return self for chaining
+	logger.DebugWithFields("listing resources in namespace", func(e onelog.Entry) {
+		e.String("api_version", req.APIVersion)
+		e.String("kind", req.Kind)
+		e.String("namespace", req.Namespace)
+		e.String("label_selector", req.LabelSelector)
+	})
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal list_resources_by_namespace request: %w", err)
+	}
+
+	respBytes, err := host.Client.HostCall(
+		"kubewarden",
+		"kubernetes",
+		"list_resources_by_namespace",
+		reqBytes,
+	)
+	if err != nil {
+		return nil, classifyHostError(err)
+	}
+	return respBytes, nil
+}
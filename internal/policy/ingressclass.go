@@ -0,0 +1,126 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+)
+
+const (
+	// ingressClassAnnotation 是已废弃但仍被广泛使用的 IngressClass 注解。
+	ingressClassAnnotation = "kubernetes.io/ingress.class"
+	// defaultIngressClassAnnotation 标记集群默认的 IngressClass。
+	defaultIngressClassAnnotation = "ingressclass.kubernetes.io/is-default-class"
+	// allowedIngressClassesWildcard 是 allowed_ingress_classes 中适用于所有命名空间的键。
+	allowedIngressClassesWildcard = "*"
+)
+
+// checkIngressClass 校验 spec.ingressClassName 指向存在的 IngressClass，
+// 未指定时（包括没有 spec 的 Ingress）要求集群存在默认 IngressClass，并按命名空间检查允许列表。
+func checkIngressClass(ingress *networkingv1.Ingress, settings Settings, report *validationReport) {
+	namespace := ingress.Metadata.Namespace
+	className := ""
+	if ingress.Spec != nil {
+		className = ingress.Spec.IngressClassName
+	}
+
+	switch {
+	case className != "":
+		_, err := getIngressClass(className, settings)
+		if errors.Is(err, ErrResourceNotFound) {
+			report.addViolation("IngressClass '%s' does not exist (referenced by spec.ingressClassName)", className)
+			return
+		}
+		if err != nil {
+			report.addHostError(fmt.Sprintf("IngressClass '%s'", className), "spec.ingressClassName", err)
+			return
+		}
+	case ingress.Metadata.Annotations[ingressClassAnnotation] != "":
+		// 旧注解的取值由控制器自行解释，不一定存在对应的 IngressClass 对象，只做允许列表检查
+		className = ingress.Metadata.Annotations[ingressClassAnnotation]
+	default:
+		defaultClass, err := findDefaultIngressClass()
+		if err != nil {
+			report.addHostError("default IngressClass", "spec.ingressClassName", err)
+			return
+		}
+		if defaultClass == "" {
+			msg := "Ingress does not set spec.ingressClassName and no IngressClass is annotated with " +
+				defaultIngressClassAnnotation + "=true"
+			if settings.MissingDefaultIngressClass == enforcementModeWarn {
+				report.addWarning(msg)
+			} else {
+				report.addViolation("%s", msg)
+			}
+			return
+		}
+		className = defaultClass
+	}
+
+	allowed, restricted := allowedIngressClasses(settings, namespace)
+	if restricted && !slices.Contains(allowed, className) {
+		report.addViolation("IngressClass '%s' is not allowed in namespace '%s' (allowed: %s)",
+			className, namespace, strings.Join(allowed, ", "))
+	}
+}
+
+// allowedIngressClasses 返回命名空间允许使用的 IngressClass 列表；
+// 第二个返回值为 false 表示该命名空间没有限制。
+func allowedIngressClasses(settings Settings, namespace string) ([]string, bool) {
+	if classes, ok := settings.AllowedIngressClasses[namespace]; ok {
+		return classes, true
+	}
+	if classes, ok := settings.AllowedIngressClasses[allowedIngressClassesWildcard]; ok {
+		return classes, true
+	}
+	return nil, false
+}
+
+// getIngressClass 通过 host capabilities 获取 IngressClass；不存在时返回 ErrResourceNotFound。
+func getIngressClass(name string, settings Settings) (*networkingv1.IngressClass, error) {
+	respBytes, err := getResource(getResourceRequest{
+		APIVersion:   "networking.k8s.io/v1",
+		Kind:         "IngressClass",
+		Name:         name,
+		DisableCache: settings.DisableCache,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(respBytes) == 0 {
+		return nil, ErrResourceNotFound
+	}
+	class := &networkingv1.IngressClass{}
+	if err := json.Unmarshal(respBytes, class); err != nil {
+		return nil, fmt.Errorf("cannot decode IngressClass '%s': %w", name, err)
+	}
+	return class, nil
+}
+
+// findDefaultIngressClass 列出集群中的 IngressClass，返回带默认注解的类名；没有时返回空字符串。
+func findDefaultIngressClass() (string, error) {
+	respBytes, err := listAllResources(listAllResourcesRequest{
+		APIVersion: "networking.k8s.io/v1",
+		Kind:       "IngressClass",
+	})
+	if err != nil {
+		return "", err
+	}
+	list := &networkingv1.IngressClassList{}
+	if err := json.Unmarshal(respBytes, list); err != nil {
+		return "", fmt.Errorf("cannot decode IngressClass list: %w", err)
+	}
+	for _, class := range list.Items {
+		if class == nil || class.Metadata == nil {
+			continue
+		}
+		if class.Metadata.Annotations[defaultIngressClassAnnotation] == "true" {
+			return class.Metadata.Name, nil
+		}
+	}
+	return "", nil
+}
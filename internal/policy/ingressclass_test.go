@@ -0,0 +1,120 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+)
+
+// classIngress 构造一个指定 IngressClass 且后端存在的 Ingress。
+func classIngress(namespace, className string) networkingv1.Ingress {
+	return networkingv1.Ingress{
+		Metadata: &metav1.ObjectMeta{
+			Name:      "class-ingress",
+			Namespace: namespace,
+		},
+		Spec: &networkingv1.IngressSpec{
+			IngressClassName: className,
+		},
+	}
+}
+
+func TestExistingIngressClassIsAccepted(t *testing.T) {
+	ingress := classIngress("default", "traefik")
+	settings := Settings{EnforceServiceExists: true, ValidateIngressClass: true}
+	if response := runValidation(t, &ingress, &settings); !response.Accepted {
+		t.Errorf("Unexpected rejection: %s", *response.Message)
+	}
+}
+
+func TestUnknownIngressClassIsRejected(t *testing.T) {
+	ingress := classIngress("default", "ngnix")
+	settings := Settings{EnforceServiceExists: true, ValidateIngressClass: true}
+	response := runValidation(t, &ingress, &settings)
+	if response.Accepted {
+		t.Fatal("Expected rejection for unknown IngressClass")
+	}
+	expected := "IngressClass 'ngnix' does not exist (referenced by spec.ingressClassName)"
+	if *response.Message != expected {
+		t.Errorf("Got '%s' instead of '%s'", *response.Message, expected)
+	}
+}
+
+func TestMissingDefaultIngressClass(t *testing.T) {
+	ingress := classIngress("default", "")
+	settings := Settings{EnforceServiceExists: true, ValidateIngressClass: true}
+
+	// 存在默认 IngressClass 时放行
+	if response := runValidation(t, &ingress, &settings); !response.Accepted {
+		t.Errorf("Unexpected rejection with a default IngressClass: %s", *response.Message)
+	}
+
+	// 没有默认 IngressClass 时拒绝
+	withoutDefault := map[string]string{
+		mockResourceKey("IngressClass", "", "nginx"): `{"kind":"IngressClass","metadata":{"name":"nginx"}}`,
+	}
+	host.Client = newMockWapcClient(withoutDefault)
+	response := validateWithClient(t, &ingress, &settings)
+	if response.Accepted {
+		t.Error("Expected rejection without a default IngressClass")
+	}
+
+	// warn 模式下只记录日志
+	settings.MissingDefaultIngressClass = enforcementModeWarn
+	host.Client = newMockWapcClient(withoutDefault)
+	if response = validateWithClient(t, &ingress, &settings); !response.Accepted {
+		t.Errorf("Expected missing_default_ingress_class=warn to accept, got: %s", *response.Message)
+	}
+}
+
+func TestIngressWithoutSpecUsesDefaultIngressClass(t *testing.T) {
+	ingress := networkingv1.Ingress{Metadata: &metav1.ObjectMeta{Name: "no-spec", Namespace: "default"}}
+	settings := Settings{EnforceServiceExists: true, ValidateIngressClass: true}
+	if response := runValidation(t, &ingress, &settings); !response.Accepted {
+		t.Errorf("Unexpected rejection with a default IngressClass: %s", *response.Message)
+	}
+
+	settings.AllowedIngressClasses = map[string][]string{"default": {"traefik"}}
+	response := runValidation(t, &ingress, &settings)
+	if response.Accepted {
+		t.Fatal("Expected the default IngressClass to be checked against the allow-list")
+	}
+	if !strings.Contains(*response.Message, "is not allowed in namespace 'default'") {
+		t.Errorf("Unexpected message: %s", *response.Message)
+	}
+}
+
+func TestAllowedIngressClasses(t *testing.T) {
+	settings := Settings{
+		EnforceServiceExists: true,
+		ValidateIngressClass: true,
+		AllowedIngressClasses: map[string][]string{
+			"default": {"nginx"},
+			"*":       {"traefik"},
+		},
+	}
+
+	ingress := classIngress("default", "traefik")
+	response := runValidation(t, &ingress, &settings)
+	if response.Accepted {
+		t.Fatal("Expected rejection for IngressClass outside the namespace allow-list")
+	}
+	expected := "IngressClass 'traefik' is not allowed in namespace 'default' (allowed: nginx)"
+	if *response.Message != expected {
+		t.Errorf("Got '%s' instead of '%s'", *response.Message, expected)
+	}
+
+	// 未单独配置的命名空间使用 "*"
+	ingress = classIngress("sandbox", "traefik")
+	if response = runValidation(t, &ingress, &settings); !response.Accepted {
+		t.Errorf("Unexpected rejection: %s", *response.Message)
+	}
+
+	// 未指定 IngressClass 时按默认类检查
+	ingress = classIngress("default", "")
+	if response = runValidation(t, &ingress, &settings); !response.Accepted {
+		t.Errorf("Expected default IngressClass nginx to be allowed, got: %s", *response.Message)
+	}
+}
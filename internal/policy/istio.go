@@ -0,0 +1,306 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	onelog "github.com/francoispqt/onelog"
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+	kubewarden "github.com/kubewarden/policy-sdk-go"
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+const (
+	istioNetworkingAPIGroup = "networking.istio.io"
+	virtualServiceKind      = "VirtualService"
+	serviceEntryKind        = "ServiceEntry"
+	// istioNetworkingAPIVersion 是查询 ServiceEntry 使用的版本，Istio 1.x 都提供。
+	istioNetworkingAPIVersion = istioNetworkingAPIGroup + "/v1beta1"
+	// defaultClusterDomain 用于把 Service 名称展开为 FQDN，与 ServiceEntry 的主机比较。
+	defaultClusterDomain = "cluster.local"
+)
+
+// virtualService 是 networking.istio.io VirtualService 中校验需要的字段子集，
+// k8s-objects 没有提供 Istio 类型。
+type virtualService struct {
+	APIVersion string              `json:"apiVersion,omitempty"`
+	Kind       string              `json:"kind,omitempty"`
+	Metadata   *metav1.ObjectMeta  `json:"metadata,omitempty"`
+	Spec       *virtualServiceSpec `json:"spec,omitempty"`
+}
+
+type virtualServiceSpec struct {
+	HTTP []*istioRoute `json:"http,omitempty"`
+	TCP  []*istioRoute `json:"tcp,omitempty"`
+	TLS  []*istioRoute `json:"tls,omitempty"`
+}
+
+// istioRoute 是 http、tcp 与 tls 路由共有的 route 列表。
+type istioRoute struct {
+	Route []*istioRouteDestination `json:"route,omitempty"`
+}
+
+type istioRouteDestination struct {
+	Destination *istioDestination `json:"destination,omitempty"`
+}
+
+type istioDestination struct {
+	Host string             `json:"host"`
+	Port *istioPortSelector `json:"port,omitempty"`
+}
+
+type istioPortSelector struct {
+	Number int32 `json:"number,omitempty"`
+}
+
+// serviceEntryList 是 ServiceEntry 列表中校验需要的字段子集。
+type serviceEntryList struct {
+	Items []*serviceEntry `json:"items"`
+}
+
+type serviceEntry struct {
+	Metadata *metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec     *serviceEntrySpec  `json:"spec,omitempty"`
+}
+
+type serviceEntrySpec struct {
+	Hosts []string `json:"hosts,omitempty"`
+}
+
+// virtualServiceDestination 是一个 destination 及其解析出的 Service 引用。
+type virtualServiceDestination struct {
+	Host string
+	// Service 为 host 对应的 Service 引用；host 不是 Service 写法时为 nil，只能由 ServiceEntry 满足。
+	Service *serviceReference
+	// Location 描述 destination 的位置，例如 http[0].route[1] (host 'reviews')。
+	Location string
+}
+
+// validateVirtualService 校验 Istio VirtualService 的 route destination 指向存在的 Service 或 ServiceEntry。
+func validateVirtualService(validationRequest *kubewarden_protocol.ValidationRequest, settings Settings) ([]byte, error) {
+	vs, err := getVirtualService(validationRequest.Request.Object)
+	if err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(fmt.Sprintf("Cannot decode %s: %s", virtualServiceKind, err)),
+			kubewarden.Code(httpBadRequestStatusCode))
+	}
+
+	logger.DebugWithFields("validating virtual service", func(e onelog.Entry) {
+		e.String("name", vs.Metadata.Name)
+		e.String("namespace", vs.Metadata.Namespace)
+	})
+
+	if !settings.IsEnforcementEnabled() {
+		return kubewarden.AcceptRequest()
+	}
+	if exempt, response, err := handleExemptions(vs.Metadata, settings); exempt {
+		return response, err
+	}
+
+	report := newValidationReport()
+	preExisting := newValidationReport()
+	oldVS := decodeOldObject(settings, validationRequest, getVirtualService)
+	lookup := newServiceLookup(settings)
+	checkChangedSeparately(extractVirtualServiceDestinations(vs), extractVirtualServiceDestinations(oldVS),
+		virtualServiceDestinationIdentity, report, preExisting,
+		func(subset []virtualServiceDestination, r *validationReport) {
+			checkVirtualServiceDestinations(settings, lookup, subset, r)
+		})
+	report.addPreExistingReport(preExisting)
+	return respondWithReport(settings, report, validationRequest.Request.Uid, vs.Metadata)
+}
+
+// virtualServiceDestinationIdentity 返回 destination 的比较标识：主机与端口，位置不参与比较。
+func virtualServiceDestinationIdentity(dest virtualServiceDestination) string {
+	if dest.Service == nil {
+		return dest.Host
+	}
+	return dest.Host + ":" + backendPortIdentity(dest.Service.Port)
+}
+
+// checkVirtualServiceDestinations 校验 destination 指向存在的 Service，或者由 ServiceEntry 注册。
+// ServiceEntry 在每次调用中单独列出，列出失败的错误写入本次调用的 report。
+func checkVirtualServiceDestinations(
+	settings Settings, lookup *serviceLookup, dests []virtualServiceDestination, report *validationReport,
+) {
+	entries := &serviceEntryHosts{}
+	hostsByLocation := map[string]string{}
+	var refs []serviceReference
+	for _, dest := range dests {
+		if dest.Service != nil && isTwoLabelHost(dest.Host) {
+			// name.namespace 与 example.com 这类外部主机写法相同，先匹配 ServiceEntry，未注册时才按 Service 校验
+			if matched, ok := entries.match(dest.Host, report); ok && matched {
+				continue
+			}
+		}
+		if dest.Service != nil {
+			hostsByLocation[dest.Location] = dest.Host
+			refs = append(refs, *dest.Service)
+			continue
+		}
+		// 不是 Service 写法的主机（例如 api.example.com）必须由 ServiceEntry 注册
+		if matched, ok := entries.match(dest.Host, report); ok && !matched {
+			report.addViolation("host '%s' of %s is neither a Service nor a ServiceEntry host", dest.Host, dest.Location)
+		}
+	}
+
+	// Service 不存在时，同名主机被 ServiceEntry 注册也视为有效
+	fallback := func(namespace, name string, svcRefs []serviceReference) bool {
+		for _, ref := range svcRefs {
+			hosts := []string{hostsByLocation[ref.Location], fmt.Sprintf("%s.%s.svc.%s", name, namespace, defaultClusterDomain)}
+			for _, candidate := range hosts {
+				matched, ok := entries.match(candidate, report)
+				if !ok {
+					// 无法列出 ServiceEntry 时结果不确定，只报告 host call 错误
+					return true
+				}
+				if !matched {
+					continue
+				}
+				logger.DebugWithFields("destination host registered by ServiceEntry", func(e onelog.Entry) {
+					e.String("host", candidate)
+				})
+				return true
+			}
+		}
+		return false
+	}
+	checkServiceReferencesWithFallback(settings, lookup, refs, fallback, report)
+}
+
+// getVirtualService 从 RAW JSON 中解析出 VirtualService 对象。
+func getVirtualService(rawJSON json.RawMessage) (*virtualService, error) {
+	if len(rawJSON) == 0 {
+		return nil, errors.New("empty VirtualService object")
+	}
+	vs := &virtualService{}
+	if err := json.Unmarshal(rawJSON, vs); err != nil {
+		return nil, err
+	}
+	if vs.Metadata == nil {
+		return nil, errors.New("VirtualService metadata is missing")
+	}
+	return vs, nil
+}
+
+// extractVirtualServiceDestinations 按出现顺序收集 http、tcp 与 tls 路由中的 destination。
+// 含通配符的主机不指向具体对象，不做校验。
+func extractVirtualServiceDestinations(vs *virtualService) []virtualServiceDestination {
+	if vs == nil || vs.Spec == nil {
+		return nil
+	}
+	var dests []virtualServiceDestination
+	collect := func(section string, routes []*istioRoute) {
+		for i, route := range routes {
+			if route == nil {
+				continue
+			}
+			for j, rd := range route.Route {
+				if rd == nil || rd.Destination == nil || rd.Destination.Host == "" || strings.Contains(rd.Destination.Host, "*") {
+					continue
+				}
+				host := rd.Destination.Host
+				dest := virtualServiceDestination{
+					Host:     host,
+					Location: fmt.Sprintf("%s[%d].route[%d] (host '%s')", section, i, j, host),
+				}
+				if namespace, name, ok := istioServiceName(host, vs.Metadata.Namespace); ok {
+					ref := serviceReference{Namespace: namespace, Name: name, Location: dest.Location}
+					if rd.Destination.Port != nil && rd.Destination.Port.Number != 0 {
+						ref.Port = &networkingv1.ServiceBackendPort{Number: rd.Destination.Port.Number}
+					}
+					dest.Service = &ref
+				}
+				dests = append(dests, dest)
+			}
+		}
+	}
+	collect("http", vs.Spec.HTTP)
+	collect("tcp", vs.Spec.TCP)
+	collect("tls", vs.Spec.TLS)
+	return dests
+}
+
+// istioServiceName 把 destination.host 解析为 Service 的命名空间与名称，支持以下写法：
+//   - name：VirtualService 所在命名空间中的 Service；
+//   - name.namespace；
+//   - name.namespace.svc 以及 name.namespace.svc.<集群域名>。
+//
+// 其他主机（例如 api.example.com）不是 Service 写法，第三个返回值为 false。
+// 两段式主机（例如 example.com）也可能是外部主机，由调用方先匹配 ServiceEntry。
+func istioServiceName(host, defaultNamespace string) (string, string, bool) {
+	labels := strings.Split(normalizeHost(host), ".")
+	for _, label := range labels {
+		if label == "" {
+			return "", "", false
+		}
+	}
+	switch {
+	case len(labels) == 1:
+		return defaultNamespace, labels[0], true
+	case len(labels) == 2:
+		return labels[1], labels[0], true
+	case labels[2] == "svc":
+		return labels[1], labels[0], true
+	default:
+		return "", "", false
+	}
+}
+
+// isTwoLabelHost 判断主机是否为 name.namespace 这样的两段式写法。
+func isTwoLabelHost(host string) bool {
+	return strings.Count(normalizeHost(host), ".") == 1
+}
+
+// serviceEntryHosts 在一次请求内缓存集群中全部 ServiceEntry 声明的主机，第一次匹配时列出。
+type serviceEntryHosts struct {
+	loaded bool
+	hosts  []string
+	err    error
+}
+
+// match 判断主机是否被某个 ServiceEntry 注册，ServiceEntry 中的通配符主机按 glob 匹配。
+// 无法列出 ServiceEntry 时第二个返回值为 false，错误只在第一次失败时写入 report。
+func (s *serviceEntryHosts) match(host string, report *validationReport) (bool, bool) {
+	if !s.loaded {
+		s.hosts, s.err = listServiceEntryHosts()
+		s.loaded = true
+		if s.err != nil {
+			report.addHostError("ServiceEntries", "", s.err)
+		}
+	}
+	if s.err != nil {
+		return false, false
+	}
+	if host == "" {
+		return false, true
+	}
+	// 主机来自 ServiceEntry，非法的 glob 只会让该条目不匹配
+	matched, _ := hostMatchesAny(host, s.hosts)
+	return matched, true
+}
+
+// listServiceEntryHosts 通过 host capabilities 列出集群内全部 ServiceEntry 的 spec.hosts。
+func listServiceEntryHosts() ([]string, error) {
+	respBytes, err := listAllResources(listAllResourcesRequest{
+		APIVersion: istioNetworkingAPIVersion,
+		Kind:       serviceEntryKind,
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := serviceEntryList{}
+	if err := json.Unmarshal(respBytes, &list); err != nil {
+		return nil, fmt.Errorf("cannot decode ServiceEntryList: %w", err)
+	}
+	var hosts []string
+	for _, entry := range list.Items {
+		if entry != nil && entry.Spec != nil {
+			hosts = append(hosts, entry.Spec.Hosts...)
+		}
+	}
+	return hosts, nil
+}
@@ -0,0 +1,148 @@
+package policy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+)
+
+// istioFixtures 返回 Istio 测试使用的 ServiceEntry。
+func istioFixtures() mockResources {
+	return mockResources{}.
+		addObject(istioNetworkingAPIVersion, serviceEntryKind,
+			&metav1.ObjectMeta{Name: "external-apis", Namespace: "default"},
+			map[string]interface{}{"spec": map[string]interface{}{
+				"hosts":      []string{"api.example.com", "*.payments.example.com", "partner.io"},
+				"ports":      []map[string]interface{}{{"number": 443, "name": "https", "protocol": "TLS"}},
+				"resolution": "DNS",
+			}}).
+		addObject(istioNetworkingAPIVersion, serviceEntryKind, &metav1.ObjectMeta{Name: "legacy", Namespace: "mesh"},
+			map[string]interface{}{"spec": map[string]interface{}{"hosts": []string{"legacy.billing.svc.cluster.local"}}})
+}
+
+// virtualServiceJSON 构造一个 http 路由包含给定 route 列表的 VirtualService。
+func virtualServiceJSON(routes string) json.RawMessage {
+	return json.RawMessage(`{"apiVersion":"networking.istio.io/v1","kind":"VirtualService",` +
+		`"metadata":{"name":"test-vs","namespace":"default"},"spec":{"hosts":["shop.example.com"],` +
+		`"http":[{"route":` + routes + `}]}}`)
+}
+
+func TestVirtualServiceDestinations(t *testing.T) {
+	tests := []struct {
+		name          string
+		vs            json.RawMessage
+		expectMessage string
+	}{
+		{
+			name: "short, namespaced and FQDN hosts",
+			vs: virtualServiceJSON(`[{"destination":{"host":"my-service","port":{"number":8080}},"weight":50},` +
+				`{"destination":{"host":"shared-service.shared"},"weight":25},` +
+				`{"destination":{"host":"shared-service.shared.svc.cluster.local","port":{"number":80}},"weight":25}]`),
+		},
+		{
+			name: "ServiceEntry hosts are accepted",
+			vs: virtualServiceJSON(`[{"destination":{"host":"api.example.com","port":{"number":443}}},` +
+				`{"destination":{"host":"eu.payments.example.com"}},` +
+				`{"destination":{"host":"legacy.billing"}}]`),
+		},
+		{
+			name:          "missing short-name service",
+			vs:            virtualServiceJSON(`[{"destination":{"host":"reviews"}}]`),
+			expectMessage: "Service 'reviews' does not exist in namespace 'default' (referenced by http[0].route[0] (host 'reviews'))",
+		},
+		{
+			name:          "missing FQDN service",
+			vs:            virtualServiceJSON(`[{"destination":{"host":"ratings.bookinfo.svc.cluster.local"}}]`),
+			expectMessage: "Service 'ratings' does not exist in namespace 'bookinfo'",
+		},
+		{
+			name: "port not exposed",
+			vs:   virtualServiceJSON(`[{"destination":{"host":"my-service.default","port":{"number":8443}}}]`),
+			expectMessage: "Service 'my-service' in namespace 'default' does not expose port 8443 " +
+				"referenced by http[0].route[0] (host 'my-service.default')",
+		},
+		{
+			name:          "external host without a ServiceEntry",
+			vs:            virtualServiceJSON(`[{"destination":{"host":"api.example.org"}}]`),
+			expectMessage: "host 'api.example.org' of http[0].route[0] (host 'api.example.org') is neither a Service nor a ServiceEntry host",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host.Client = newMockWapcClient(istioFixtures())
+			settings := Settings{EnforceServiceExists: true}
+			response := validateKindWithClient(t, istioNetworkingAPIGroup, virtualServiceKind, tt.vs, &settings)
+			if tt.expectMessage == "" {
+				if !response.Accepted {
+					t.Errorf("Unexpected rejection: %s", *response.Message)
+				}
+				return
+			}
+			if response.Accepted {
+				t.Fatal("Expected rejection for a broken VirtualService destination")
+			}
+			if !strings.Contains(*response.Message, tt.expectMessage) {
+				t.Errorf("Expected message to contain '%s', got '%s'", tt.expectMessage, *response.Message)
+			}
+		})
+	}
+}
+
+func TestVirtualServiceTCPAndTLSRoutes(t *testing.T) {
+	host.Client = newMockWapcClient(istioFixtures())
+	vs := json.RawMessage(`{"apiVersion":"networking.istio.io/v1","kind":"VirtualService",` +
+		`"metadata":{"name":"test-vs","namespace":"default"},"spec":{` +
+		`"tcp":[{"route":[{"destination":{"host":"my-service","port":{"number":9090}}}]}],` +
+		`"tls":[{"route":[{"destination":{"host":"db","port":{"number":5432}}}]}]}}`)
+	settings := Settings{EnforceServiceExists: true}
+	response := validateKindWithClient(t, istioNetworkingAPIGroup, virtualServiceKind, vs, &settings)
+	if response.Accepted {
+		t.Fatal("Expected rejection for a missing TLS route destination")
+	}
+	if !strings.Contains(*response.Message, "Service 'db' does not exist in namespace 'default' (referenced by tls[0].route[0] (host 'db'))") {
+		t.Errorf("Unexpected message: %s", *response.Message)
+	}
+	if strings.Contains(*response.Message, "my-service") {
+		t.Errorf("Did not expect the existing TCP destination to be reported: %s", *response.Message)
+	}
+}
+
+func TestTwoLabelServiceEntryHostIsNotLookedUpAsService(t *testing.T) {
+	client := &countingWapcClient{mockWapcClient: newMockWapcClient(istioFixtures()), calls: map[string]int{}}
+	host.Client = client
+	vs := virtualServiceJSON(`[{"destination":{"host":"partner.io","port":{"number":443}}}]`)
+	settings := Settings{EnforceServiceExists: true}
+	response := validateKindWithClient(t, istioNetworkingAPIGroup, virtualServiceKind, vs, &settings)
+	if !response.Accepted {
+		t.Fatalf("Unexpected rejection: %s", *response.Message)
+	}
+	if client.calls["get_resource/Service"] != 0 {
+		t.Errorf("Expected no Service lookup for a ServiceEntry host, got %v", client.calls)
+	}
+}
+
+func TestIstioServiceName(t *testing.T) {
+	tests := []struct {
+		host      string
+		namespace string
+		name      string
+		ok        bool
+	}{
+		{host: "reviews", namespace: "default", name: "reviews", ok: true},
+		{host: "reviews.bookinfo", namespace: "bookinfo", name: "reviews", ok: true},
+		{host: "reviews.bookinfo.svc", namespace: "bookinfo", name: "reviews", ok: true},
+		{host: "Reviews.Bookinfo.svc.cluster.local.", namespace: "bookinfo", name: "reviews", ok: true},
+		{host: "api.example.com"},
+		{host: "reviews..svc"},
+	}
+	for _, tt := range tests {
+		namespace, name, ok := istioServiceName(tt.host, "default")
+		if ok != tt.ok || namespace != tt.namespace || name != tt.name {
+			t.Errorf("istioServiceName(%q) = %q, %q, %v; want %q, %q, %v",
+				tt.host, namespace, name, ok, tt.namespace, tt.name, tt.ok)
+		}
+	}
+}
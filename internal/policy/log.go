@@ -0,0 +1,25 @@
+package policy
+
+import (
+	"io"
+
+	onelog "github.com/francoispqt/onelog"
+	kubewarden "github.com/kubewarden/policy-sdk-go"
+)
+
+// This is not a good practice in general. Policy authors should avoid using global variables in the final code
+//
+//nolint:gochecknoglobals // Allowing global variables just to make the template code simple.
+var (
+	logWriter = kubewarden.KubewardenLogWriter{}
+	logger    = onelog.New(
+		&logWriter,
+		onelog.ALL, // shortcut for onelog.DEBUG|onelog.INFO|onelog.WARN|onelog.ERROR|onelog.FATAL
+	)
+)
+
+// SetLogWriter 将策略日志写入 w。wasm 运行时之外默认的 KubewardenLogWriter 会写到标准输出，
+// 离线 CLI 等调用方可以借此把日志重定向或丢弃。
+func SetLogWriter(w io.Writer) {
+	logger = onelog.New(w, onelog.ALL)
+}
@@ -0,0 +1,99 @@
+package policy
+
+import (
+	"strings"
+
+	onelog "github.com/francoispqt/onelog"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+)
+
+// normalizeBackendPorts 根据引用的 Service 规范化 Ingress 中的 ServiceBackendPort，返回是否修改了对象：
+//   - 未指定端口且 Service 只有一个端口时，填入该端口号；
+//   - 端口名与 Service 的端口名不一致，但可以唯一确定目标端口时，改写为对应的端口号。
+//
+// 无法获取的 Service 保持原样，由后续的存在性校验报告。
+func normalizeBackendPorts(ingress *networkingv1.Ingress, lookup *serviceLookup) bool {
+	if ingress.Spec == nil {
+		return false
+	}
+	namespace := ingress.Metadata.Namespace
+	changed := false
+	normalize := func(backend *networkingv1.IngressBackend, location string) {
+		name := extractServiceNameFromBackend(backend)
+		if name == "" || isClaimedALBActionBackend(backend, lookup.settings) {
+			return
+		}
+		// lookup 缓存查询结果，后续的存在性校验不会再次查询同一个 Service
+		service, _, _ := lookup.get(namespace, name)
+		if service == nil {
+			return
+		}
+		port := normalizedBackendPort(service, backend.Service.Port)
+		if port == nil {
+			return
+		}
+		logger.DebugWithFields("normalizing backend port", func(e onelog.Entry) {
+			e.String("service", name)
+			e.String("location", location)
+			e.String("from", formatBackendPort(backend.Service.Port))
+			e.String("to", formatBackendPort(port))
+		})
+		backend.Service.Port = port
+		changed = true
+	}
+
+	normalize(ingress.Spec.DefaultBackend, "defaultBackend")
+	for i, rule := range ingress.Spec.Rules {
+		if rule == nil || rule.HTTP == nil {
+			continue
+		}
+		for j, path := range rule.HTTP.Paths {
+			if path != nil {
+				normalize(path.Backend, formatPathLocation(i, j, rule.Host, path.Path))
+			}
+		}
+	}
+	return changed
+}
+
+// normalizedBackendPort 返回规范化后的端口；不需要或无法规范化时返回 nil。
+// 端口号与能匹配的端口名保持不变。
+func normalizedBackendPort(service *corev1.Service, port *networkingv1.ServiceBackendPort) *networkingv1.ServiceBackendPort {
+	if service.Spec == nil || service.Spec.Type == "ExternalName" {
+		return nil
+	}
+	var ports []*corev1.ServicePort
+	for _, sp := range service.Spec.Ports {
+		if sp != nil && sp.Port != nil {
+			ports = append(ports, sp)
+		}
+	}
+
+	if port == nil || (port.Number == 0 && port.Name == "") {
+		if len(ports) == 1 {
+			return &networkingv1.ServiceBackendPort{Number: *ports[0].Port}
+		}
+		return nil
+	}
+	if port.Number != 0 || servicePortExists(service, port) {
+		return nil
+	}
+	// 端口名不存在：Service 只有一个端口，或者只有一个端口名仅大小写不同，才能确定目标端口
+	if len(ports) == 1 {
+		return &networkingv1.ServiceBackendPort{Number: *ports[0].Port}
+	}
+	var match *corev1.ServicePort
+	for _, sp := range ports {
+		if strings.EqualFold(sp.Name, port.Name) {
+			if match != nil {
+				return nil
+			}
+			match = sp
+		}
+	}
+	if match == nil {
+		return nil
+	}
+	return &networkingv1.ServiceBackendPort{Number: *match.Port}
+}
@@ -0,0 +1,104 @@
+package policy
+
+import (
+	"encoding/json"
+	"testing"
+
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+// mutationFixtures 返回用于端口规范化的 Service。
+func mutationFixtures() map[string]string {
+	return map[string]string{
+		mockResourceKey("Service", "default", "single-port-service"): `{"kind":"Service","apiVersion":"v1",` +
+			`"metadata":{"name":"single-port-service","namespace":"default"},` +
+			`"spec":{"type":"ClusterIP","ports":[{"name":"web","port":8080,"protocol":"TCP"}]}}`,
+		mockResourceKey("Service", "default", "upper-case-service"): `{"kind":"Service","apiVersion":"v1",` +
+			`"metadata":{"name":"upper-case-service","namespace":"default"},` +
+			`"spec":{"type":"ClusterIP","ports":[{"name":"HTTP","port":80,"protocol":"TCP"},{"name":"grpc","port":9000,"protocol":"TCP"}]}}`,
+	}
+}
+
+// mutatedIngress 从响应中解析修改后的 Ingress，没有修改时返回 nil。
+func mutatedIngress(t *testing.T, response kubewarden_protocol.ValidationResponse) *networkingv1.Ingress {
+	t.Helper()
+	if response.MutatedObject == nil {
+		return nil
+	}
+	raw, err := json.Marshal(response.MutatedObject)
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	ingress := &networkingv1.Ingress{}
+	if err := json.Unmarshal(raw, ingress); err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	return ingress
+}
+
+func TestNormalizeBackendPorts(t *testing.T) {
+	tests := []struct {
+		name         string
+		service      string
+		port         networkingv1.ServiceBackendPort
+		expectAccept bool
+		// expectPort 为 0 表示不应修改对象
+		expectPort int32
+	}{
+		{name: "missing port on single-port service", service: "single-port-service", expectAccept: true, expectPort: 8080},
+		{name: "wrong name on single-port service", service: "single-port-service", port: networkingv1.ServiceBackendPort{Name: "http"}, expectAccept: true, expectPort: 8080},
+		{name: "name differing only in case", service: "upper-case-service", port: networkingv1.ServiceBackendPort{Name: "http"}, expectAccept: true, expectPort: 80},
+		{name: "valid name is kept", service: "my-service", port: networkingv1.ServiceBackendPort{Name: "web"}, expectAccept: true},
+		{name: "valid number is kept", service: "single-port-service", port: networkingv1.ServiceBackendPort{Number: 8080}, expectAccept: true},
+		{name: "unknown name on multi-port service", service: "my-service", port: networkingv1.ServiceBackendPort{Name: "admin"}},
+		{name: "unknown number is not rewritten", service: "single-port-service", port: networkingv1.ServiceBackendPort{Number: 80}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host.Client = newMockWapcClient(mutationFixtures())
+			settings := Settings{EnforceServiceExists: true, NormalizeBackendPorts: true}
+			response := validateWithClient(t, endpointIngress(tt.service, tt.port), &settings)
+			if response.Accepted != tt.expectAccept {
+				t.Fatalf("Expected accepted=%v, got %v (message: %v)", tt.expectAccept, response.Accepted, response.Message)
+			}
+			mutated := mutatedIngress(t, response)
+			if tt.expectPort == 0 {
+				if mutated != nil {
+					t.Errorf("Expected no mutation, got %+v", mutated.Spec.DefaultBackend.Service.Port)
+				}
+				return
+			}
+			if mutated == nil {
+				t.Fatal("Expected a mutated Ingress")
+			}
+			port := mutated.Spec.DefaultBackend.Service.Port
+			if port == nil || port.Number != tt.expectPort || port.Name != "" {
+				t.Errorf("Expected port %d, got %+v", tt.expectPort, port)
+			}
+		})
+	}
+}
+
+func TestNormalizeBackendPortsDisabled(t *testing.T) {
+	host.Client = newMockWapcClient(mutationFixtures())
+	settings := Settings{EnforceServiceExists: true}
+	response := validateWithClient(t, endpointIngress("single-port-service", networkingv1.ServiceBackendPort{Name: "http"}), &settings)
+	if response.Accepted {
+		t.Error("Expected rejection without normalize_backend_ports")
+	}
+}
+
+func TestNormalizeBackendPortsLooksUpEachServiceOnce(t *testing.T) {
+	client := &countingWapcClient{mockWapcClient: newMockWapcClient(mutationFixtures()), calls: map[string]int{}}
+	host.Client = client
+	settings := Settings{EnforceServiceExists: true, NormalizeBackendPorts: true}
+	response := validateWithClient(t, endpointIngress("single-port-service", networkingv1.ServiceBackendPort{Name: "http"}), &settings)
+	if mutatedIngress(t, response) == nil {
+		t.Fatal("Expected a mutated Ingress")
+	}
+	if client.calls["get_resource/Service"] != 1 {
+		t.Errorf("Expected the Service to be fetched once for normalisation and validation, got %v", client.calls)
+	}
+}
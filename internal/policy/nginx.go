@@ -0,0 +1,139 @@
+package policy
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+)
+
+// annotationControllerIngressNginx 是 ingress-nginx 注解提取器的名称。
+const annotationControllerIngressNginx = "ingress-nginx"
+
+// nginxAnnotationPrefix 是 ingress-nginx 默认的注解前缀。
+const nginxAnnotationPrefix = "nginx.ingress.kubernetes.io/"
+
+// nginxSecretAnnotations 是值为 Secret 名称（name 或 namespace/name）的 ingress-nginx 注解。
+//
+//nolint:gochecknoglobals // 只读的查找表
+var nginxSecretAnnotations = []string{
+	nginxAnnotationPrefix + "auth-secret",
+	nginxAnnotationPrefix + "auth-tls-secret",
+	nginxAnnotationPrefix + "proxy-ssl-secret",
+}
+
+const (
+	// nginxDefaultBackendAnnotation 的值为 Service 名称（name 或 namespace/name）。
+	nginxDefaultBackendAnnotation = nginxAnnotationPrefix + "default-backend"
+	// nginxMirrorTargetAnnotation 的值为镜像请求的目标 URL。
+	nginxMirrorTargetAnnotation = nginxAnnotationPrefix + "mirror-target"
+)
+
+// extractNginxAnnotationReferences 从 ingress-nginx 注解中提取 Secret 与 Service 引用。
+// mirror-target 只有在主机为集群内 Service 域名（name.namespace.svc[.<集群域名>]）时才产生引用，
+// 指向外部地址的 URL 不做校验。
+func extractNginxAnnotationReferences(ing *networkingv1.Ingress) (annotationReferences, []string) {
+	var refs annotationReferences
+	var problems []string
+	annotations := ing.Metadata.Annotations
+	namespace := ing.Metadata.Namespace
+
+	for _, key := range nginxSecretAnnotations {
+		value, ok := annotations[key]
+		if !ok {
+			continue
+		}
+		secretNamespace, name, err := splitNamespacedName(value, namespace)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s is invalid: %s", formatAnnotationLocation(key), err))
+			continue
+		}
+		refs.Secrets = append(refs.Secrets, annotationSecretReference{
+			Namespace:  secretNamespace,
+			Name:       name,
+			Annotation: key,
+		})
+	}
+
+	if value, ok := annotations[nginxDefaultBackendAnnotation]; ok {
+		serviceNamespace, name, err := splitNamespacedName(value, namespace)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s is invalid: %s",
+				formatAnnotationLocation(nginxDefaultBackendAnnotation), err))
+		} else {
+			refs.Services = append(refs.Services, serviceReference{
+				Namespace: serviceNamespace,
+				Name:      name,
+				Location:  formatAnnotationLocation(nginxDefaultBackendAnnotation),
+			})
+		}
+	}
+
+	if value, ok := annotations[nginxMirrorTargetAnnotation]; ok {
+		ref, isService, err := mirrorTargetServiceReference(value)
+		switch {
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("%s is invalid: %s",
+				formatAnnotationLocation(nginxMirrorTargetAnnotation), err))
+		case isService:
+			refs.Services = append(refs.Services, ref)
+		}
+	}
+
+	sort.Strings(problems)
+	return refs, problems
+}
+
+// mirrorTargetServiceReference 解析 mirror-target URL，URL 未写端口时使用协议的默认端口。
+// URL 中可以使用 nginx 变量（例如 http://mirror.team-a.svc:8080$request_uri），
+// 只要 scheme://host[:port] 部分是字面值，变量之前的主机与端口照常校验。
+// 第二个返回值为 false 表示目标不是集群内 Service，或协议、主机、端口中包含 nginx 变量而无法静态确定。
+func mirrorTargetServiceReference(value string) (serviceReference, bool, error) {
+	value = strings.TrimSpace(value)
+	scheme, rest, found := strings.Cut(value, "://")
+	if strings.Contains(scheme, "$") {
+		// 协议使用 nginx 变量（例如 $scheme://$host）时无法静态解析，跳过
+		return serviceReference{}, false, nil
+	}
+	if !found || (scheme != "http" && scheme != "https") {
+		return serviceReference{}, false, fmt.Errorf("'%s' is not an http or https URL", value)
+	}
+	// 主机与端口截止到路径、查询、片段或第一个 nginx 变量
+	authority := rest
+	if end := strings.IndexAny(rest, "/?#$"); end >= 0 {
+		authority = rest[:end]
+		if rest[end] == '$' && (authority == "" || strings.HasSuffix(authority, ":")) {
+			// 主机或端口来自 nginx 变量，无法静态确定
+			return serviceReference{}, false, nil
+		}
+	}
+	target, err := url.Parse(scheme + "://" + authority)
+	if err != nil || target.Hostname() == "" {
+		return serviceReference{}, false, fmt.Errorf("'%s' has an invalid host", value)
+	}
+	labels := strings.Split(normalizeHost(target.Hostname()), ".")
+	if len(labels) < 3 || labels[2] != "svc" || labels[0] == "" || labels[1] == "" {
+		return serviceReference{}, false, nil
+	}
+
+	port := &networkingv1.ServiceBackendPort{Number: 80}
+	if target.Scheme == "https" {
+		port.Number = 443
+	}
+	if rawPort := target.Port(); rawPort != "" {
+		number, err := strconv.ParseInt(rawPort, 10, 32)
+		if err != nil || number <= 0 || number > 65535 {
+			return serviceReference{}, false, fmt.Errorf("URL '%s' has an invalid port", value)
+		}
+		port.Number = int32(number)
+	}
+	return serviceReference{
+		Namespace: labels[1],
+		Name:      labels[0],
+		Port:      port,
+		Location:  formatAnnotationLocation(nginxMirrorTargetAnnotation),
+	}, true, nil
+}
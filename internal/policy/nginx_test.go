@@ -0,0 +1,131 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+// nginxAnnotationFixtures 返回注解测试使用的 Secret。
+func nginxAnnotationFixtures() mockResources {
+	return mockResources{}.addSecret("shared", "auth-users", "Opaque")
+}
+
+func TestNginxAnnotationReferences(t *testing.T) {
+	tests := []struct {
+		name          string
+		annotations   map[string]string
+		expectMessage string
+	}{
+		{
+			name: "all referenced objects exist",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/auth-secret":      "shared/auth-users",
+				"nginx.ingress.kubernetes.io/auth-tls-secret":  "default/tls-secret",
+				"nginx.ingress.kubernetes.io/proxy-ssl-secret": "tls-secret",
+				"nginx.ingress.kubernetes.io/default-backend":  "my-service",
+				"nginx.ingress.kubernetes.io/mirror-target":    "http://shared-service.shared.svc.cluster.local/mirror",
+			},
+		},
+		{
+			name:          "missing auth secret",
+			annotations:   map[string]string{"nginx.ingress.kubernetes.io/auth-secret": "missing-auth"},
+			expectMessage: "Secret 'missing-auth' does not exist in namespace 'default' (referenced by annotation 'nginx.ingress.kubernetes.io/auth-secret')",
+		},
+		{
+			name:          "missing secret in another namespace",
+			annotations:   map[string]string{"nginx.ingress.kubernetes.io/auth-tls-secret": "shared/client-ca"},
+			expectMessage: "Secret 'client-ca' does not exist in namespace 'shared' (referenced by annotation 'nginx.ingress.kubernetes.io/auth-tls-secret')",
+		},
+		{
+			name:          "missing default backend",
+			annotations:   map[string]string{"nginx.ingress.kubernetes.io/default-backend": "fallback"},
+			expectMessage: "Service 'fallback' does not exist in namespace 'default' (referenced by annotation 'nginx.ingress.kubernetes.io/default-backend')",
+		},
+		{
+			name:        "mirror target port not exposed",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/mirror-target": "https://my-service.default.svc:8443/mirror"},
+			expectMessage: "Service 'my-service' in namespace 'default' does not expose port 8443 " +
+				"referenced by annotation 'nginx.ingress.kubernetes.io/mirror-target'",
+		},
+		{
+			name:        "external mirror target is not checked",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/mirror-target": "https://mirror.example.com/$request_uri"},
+		},
+		{
+			name:        "mirror target with a literal host and a variable path",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/mirror-target": "http://missing-mirror.default.svc$request_uri"},
+			expectMessage: "Service 'missing-mirror' does not exist in namespace 'default' " +
+				"(referenced by annotation 'nginx.ingress.kubernetes.io/mirror-target')",
+		},
+		{
+			name:        "mirror target with a literal port and a variable path",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/mirror-target": "https://my-service.default.svc:8443$request_uri"},
+			expectMessage: "Service 'my-service' in namespace 'default' does not expose port 8443 " +
+				"referenced by annotation 'nginx.ingress.kubernetes.io/mirror-target'",
+		},
+		{
+			name:        "mirror target port built from variables is not checked",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/mirror-target": "http://missing-mirror.default.svc:$mirror_port/"},
+		},
+		{
+			name:        "mirror target built from variables is not checked",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/mirror-target": "$scheme://$host/mirror"},
+		},
+		{
+			name:          "malformed namespaced name",
+			annotations:   map[string]string{"nginx.ingress.kubernetes.io/proxy-ssl-secret": "a/b/c"},
+			expectMessage: "annotation 'nginx.ingress.kubernetes.io/proxy-ssl-secret' is invalid: 'a/b/c' is not of the form name or namespace/name",
+		},
+		{
+			name:          "mirror target without scheme",
+			annotations:   map[string]string{"nginx.ingress.kubernetes.io/mirror-target": "my-service.default.svc"},
+			expectMessage: "annotation 'nginx.ingress.kubernetes.io/mirror-target' is invalid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host.Client = newMockWapcClient(nginxAnnotationFixtures())
+			ingress := pathIngress("my-service")
+			ingress.Metadata.Annotations = tt.annotations
+			settings := Settings{
+				EnforceServiceExists:           true,
+				AnnotationReferenceControllers: []string{annotationControllerIngressNginx},
+			}
+			response := validateWithClient(t, ingress, &settings)
+			if tt.expectMessage == "" {
+				if !response.Accepted {
+					t.Errorf("Unexpected rejection: %s", *response.Message)
+				}
+				return
+			}
+			if response.Accepted {
+				t.Fatal("Expected rejection for a broken annotation reference")
+			}
+			if !strings.Contains(*response.Message, tt.expectMessage) {
+				t.Errorf("Expected message to contain '%s', got '%s'", tt.expectMessage, *response.Message)
+			}
+		})
+	}
+}
+
+func TestNginxAnnotationsIgnoredWhenControllerDisabled(t *testing.T) {
+	host.Client = newMockWapcClient(nil)
+	ingress := pathIngress("my-service")
+	ingress.Metadata.Annotations = map[string]string{"nginx.ingress.kubernetes.io/auth-secret": "missing-auth"}
+	settings := Settings{EnforceServiceExists: true}
+	if response := validateWithClient(t, ingress, &settings); !response.Accepted {
+		t.Errorf("Unexpected rejection: %s", *response.Message)
+	}
+}
+
+func TestUnknownAnnotationReferenceController(t *testing.T) {
+	settings := Settings{AnnotationReferenceControllers: []string{"haproxy"}}
+	valid, err := settings.Valid()
+	if valid || err == nil {
+		t.Fatal("Expected an unknown controller to be rejected")
+	}
+	if !strings.Contains(err.Error(), "must be one of aws-load-balancer-controller, ingress-nginx, traefik") {
+		t.Errorf("Expected the error to list the known controllers, got '%s'", err)
+	}
+}
@@ -0,0 +1,132 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	onelog "github.com/francoispqt/onelog"
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+	kubewarden "github.com/kubewarden/policy-sdk-go"
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+// route.openshift.io/v1 Route 的 API 组与 Kind。
+const (
+	openShiftRouteAPIGroup = "route.openshift.io"
+	openShiftRouteKind     = "Route"
+)
+
+// openShiftRoute 是 route.openshift.io/v1 Route 中校验需要的字段子集，
+// k8s-objects 没有提供 OpenShift 类型。
+type openShiftRoute struct {
+	APIVersion string              `json:"apiVersion,omitempty"`
+	Kind       string              `json:"kind,omitempty"`
+	Metadata   *metav1.ObjectMeta  `json:"metadata,omitempty"`
+	Spec       *openShiftRouteSpec `json:"spec,omitempty"`
+}
+
+type openShiftRouteSpec struct {
+	To                *openShiftRouteTarget   `json:"to,omitempty"`
+	AlternateBackends []*openShiftRouteTarget `json:"alternateBackends,omitempty"`
+	Port              *openShiftRoutePort     `json:"port,omitempty"`
+}
+
+// openShiftRouteTarget 对应 spec.to 与 spec.alternateBackends 中的后端，kind 只能为 Service（默认）。
+type openShiftRouteTarget struct {
+	Kind string `json:"kind,omitempty"`
+	Name string `json:"name"`
+}
+
+// openShiftRoutePort 对应 spec.port，targetPort 为端口号或端口名，作用于 Route 的全部后端。
+type openShiftRoutePort struct {
+	TargetPort *intOrStringPort `json:"targetPort,omitempty"`
+}
+
+// validateOpenShiftRoute 校验 OpenShift Route 的 spec.to 与 spec.alternateBackends 指向存在的 Service，
+// 并且 spec.port.targetPort 与这些 Service 的端口匹配。
+func validateOpenShiftRoute(validationRequest *kubewarden_protocol.ValidationRequest, settings Settings) ([]byte, error) {
+	route, err := getOpenShiftRoute(validationRequest.Request.Object)
+	if err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(fmt.Sprintf("Cannot decode %s: %s", openShiftRouteKind, err)),
+			kubewarden.Code(httpBadRequestStatusCode))
+	}
+
+	logger.DebugWithFields("validating openshift route", func(e onelog.Entry) {
+		e.String("name", route.Metadata.Name)
+		e.String("namespace", route.Metadata.Namespace)
+	})
+
+	if !settings.IsEnforcementEnabled() {
+		return kubewarden.AcceptRequest()
+	}
+	if exempt, response, err := handleExemptions(route.Metadata, settings); exempt {
+		return response, err
+	}
+
+	report := newValidationReport()
+	preExisting := newValidationReport()
+	refs, problems := extractOpenShiftRouteReferences(route)
+	oldRefs, oldProblems := extractOpenShiftRouteReferences(decodeOldObject(settings, validationRequest, getOpenShiftRoute))
+	checkChangedSeparately(problems, oldProblems, stringIdentity, report, preExisting, addViolations)
+	lookup := newServiceLookup(settings)
+	checkChangedSeparately(refs, oldRefs, serviceReferenceIdentity, report, preExisting,
+		func(subset []serviceReference, r *validationReport) {
+			checkServiceReferences(settings, lookup, subset, r)
+		})
+	report.addPreExistingReport(preExisting)
+	return respondWithReport(settings, report, validationRequest.Request.Uid, route.Metadata)
+}
+
+// getOpenShiftRoute 从 RAW JSON 中解析出 Route 对象。
+func getOpenShiftRoute(rawJSON json.RawMessage) (*openShiftRoute, error) {
+	if len(rawJSON) == 0 {
+		return nil, errors.New("empty Route object")
+	}
+	route := &openShiftRoute{}
+	if err := json.Unmarshal(rawJSON, route); err != nil {
+		return nil, err
+	}
+	if route.Metadata == nil {
+		return nil, errors.New("Route metadata is missing")
+	}
+	return route, nil
+}
+
+// extractOpenShiftRouteReferences 按出现顺序收集 spec.to 与 spec.alternateBackends 中的 Service 引用，
+// 每个引用都带上 spec.port.targetPort。Route 只能引用同一命名空间中的 Service。
+func extractOpenShiftRouteReferences(route *openShiftRoute) ([]serviceReference, []string) {
+	if route == nil || route.Spec == nil {
+		return nil, nil
+	}
+	var targetPort *networkingv1.ServiceBackendPort
+	if route.Spec.Port != nil && route.Spec.Port.TargetPort != nil {
+		port := route.Spec.Port.TargetPort.ServiceBackendPort
+		targetPort = &port
+	}
+
+	var refs []serviceReference
+	var problems []string
+	collect := func(target *openShiftRouteTarget, location string) {
+		if target == nil || target.Name == "" {
+			return
+		}
+		if target.Kind != "" && target.Kind != serviceKind {
+			problems = append(problems, fmt.Sprintf("%s has unsupported kind '%s'", location, target.Kind))
+			return
+		}
+		refs = append(refs, serviceReference{
+			Namespace:  route.Metadata.Namespace,
+			Name:       target.Name,
+			TargetPort: targetPort,
+			Location:   location,
+		})
+	}
+	collect(route.Spec.To, "spec.to")
+	for i, backend := range route.Spec.AlternateBackends {
+		collect(backend, fmt.Sprintf("spec.alternateBackends[%d]", i))
+	}
+	return refs, problems
+}
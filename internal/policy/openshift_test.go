@@ -0,0 +1,120 @@
+package policy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	"github.com/kubewarden/k8s-objects/apimachinery/pkg/util/intstr"
+)
+
+// openShiftFixtures 返回 Route 测试使用的、声明了 targetPort 的 Service。
+func openShiftFixtures() mockResources {
+	httpTarget, adminTarget := intstr.FromInt64(8080), intstr.FromString("admin-port")
+	return mockResources{}.addService("default", "frontend", &corev1.ServiceSpec{
+		Type: "ClusterIP",
+		Ports: []*corev1.ServicePort{
+			{Name: "http", Port: int32Ptr(80), TargetPort: &httpTarget, Protocol: "TCP"},
+			{Name: "admin", Port: int32Ptr(9000), TargetPort: &adminTarget, Protocol: "TCP"},
+		},
+	})
+}
+
+// openShiftRouteJSON 构造一个 Route，alternateBackends 与 port 为空字符串时不设置。
+func openShiftRouteJSON(to, alternateBackends, port string) json.RawMessage {
+	spec := `{"host":"shop.apps.example.com","to":` + to
+	if alternateBackends != "" {
+		spec += `,"alternateBackends":` + alternateBackends
+	}
+	if port != "" {
+		spec += `,"port":` + port
+	}
+	spec += `}`
+	return json.RawMessage(`{"apiVersion":"route.openshift.io/v1","kind":"Route",` +
+		`"metadata":{"name":"test-route","namespace":"default"},"spec":` + spec + `}`)
+}
+
+func TestOpenShiftRoute(t *testing.T) {
+	tests := []struct {
+		name          string
+		route         json.RawMessage
+		expectMessage string
+	}{
+		{
+			name: "to and alternate backends exist without a port",
+			route: openShiftRouteJSON(`{"kind":"Service","name":"frontend","weight":80}`,
+				`[{"kind":"Service","name":"my-service","weight":20}]`, ""),
+		},
+		{
+			name:  "targetPort matches a service port number",
+			route: openShiftRouteJSON(`{"kind":"Service","name":"frontend"}`, "", `{"targetPort":80}`),
+		},
+		{
+			name:  "targetPort matches a numeric service targetPort",
+			route: openShiftRouteJSON(`{"kind":"Service","name":"frontend"}`, "", `{"targetPort":8080}`),
+		},
+		{
+			name:  "targetPort matches a port name or a named targetPort",
+			route: openShiftRouteJSON(`{"name":"frontend"}`, "", `{"targetPort":"admin-port"}`),
+		},
+		{
+			name:          "missing service in spec.to",
+			route:         openShiftRouteJSON(`{"kind":"Service","name":"missing-service"}`, "", ""),
+			expectMessage: "Service 'missing-service' does not exist in namespace 'default' (referenced by spec.to)",
+		},
+		{
+			name: "missing alternate backend",
+			route: openShiftRouteJSON(`{"kind":"Service","name":"frontend","weight":50}`,
+				`[{"kind":"Service","name":"my-service"},{"kind":"Service","name":"canary","weight":50}]`, ""),
+			expectMessage: "Service 'canary' does not exist in namespace 'default' (referenced by spec.alternateBackends[1])",
+		},
+		{
+			name: "named targetPort exposed by every backend",
+			route: openShiftRouteJSON(`{"kind":"Service","name":"frontend"}`,
+				`[{"kind":"Service","name":"my-service"}]`, `{"targetPort":"http"}`),
+		},
+		{
+			name: "targetPort not exposed",
+			route: openShiftRouteJSON(`{"kind":"Service","name":"frontend"}`,
+				`[{"kind":"Service","name":"my-service"}]`, `{"targetPort":"admin-port"}`),
+			expectMessage: "Service 'my-service' in namespace 'default' does not expose target port 'admin-port' " +
+				"referenced by spec.alternateBackends[0]",
+		},
+		{
+			name:          "unsupported backend kind",
+			route:         openShiftRouteJSON(`{"kind":"Deployment","name":"frontend"}`, "", ""),
+			expectMessage: "spec.to has unsupported kind 'Deployment'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host.Client = newMockWapcClient(openShiftFixtures())
+			settings := Settings{EnforceServiceExists: true}
+			response := validateKindWithClient(t, openShiftRouteAPIGroup, openShiftRouteKind, tt.route, &settings)
+			if tt.expectMessage == "" {
+				if !response.Accepted {
+					t.Errorf("Unexpected rejection: %s", *response.Message)
+				}
+				return
+			}
+			if response.Accepted {
+				t.Fatal("Expected rejection for a broken Route backend")
+			}
+			if !strings.Contains(*response.Message, tt.expectMessage) {
+				t.Errorf("Expected message to contain '%s', got '%s'", tt.expectMessage, *response.Message)
+			}
+		})
+	}
+}
+
+func TestServiceTargetPortExistsSkipsExternalName(t *testing.T) {
+	host.Client = newMockWapcClient(nil)
+	route := openShiftRouteJSON(`{"kind":"Service","name":"external-service"}`, "", `{"targetPort":"https"}`)
+	settings := Settings{EnforceServiceExists: true}
+	response := validateKindWithClient(t, openShiftRouteAPIGroup, openShiftRouteKind, route, &settings)
+	if !response.Accepted {
+		t.Errorf("Expected ExternalName Services to skip the targetPort check, got: %s", *response.Message)
+	}
+}
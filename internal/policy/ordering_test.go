@@ -0,0 +1,58 @@
+package policy
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// recordingWapcClient 按顺序记录 get_resource 查询的资源名称。
+type recordingWapcClient struct {
+	*mockWapcClient
+	names []string
+}
+
+func (c *recordingWapcClient) HostCall(binding, namespace, operation string, payload []byte) ([]byte, error) {
+	if operation == "get_resource" {
+		req := map[string]interface{}{}
+		if err := json.Unmarshal(payload, &req); err == nil {
+			name, _ := req["name"].(string)
+			c.names = append(c.names, name)
+		}
+	}
+	return c.mockWapcClient.HostCall(binding, namespace, operation, payload)
+}
+
+func TestBackendChecksAreDeterministic(t *testing.T) {
+	settings := Settings{EnforceServiceExists: true}
+	ingress := pathIngress("zeta-service", "my-service", "alpha-service", "zeta-service")
+
+	var firstMessage string
+	for i := 0; i < 20; i++ {
+		client := &recordingWapcClient{mockWapcClient: newMockWapcClient(nil)}
+		host.Client = client
+		response := validateWithClient(t, ingress, &settings)
+		if response.Accepted {
+			t.Fatal("Expected rejection for missing Services")
+		}
+
+		expectedCalls := []string{"alpha-service", "my-service", "zeta-service"}
+		if !reflect.DeepEqual(client.names, expectedCalls) {
+			t.Fatalf("Expected host calls in order %v, got %v", expectedCalls, client.names)
+		}
+		if i == 0 {
+			firstMessage = *response.Message
+			continue
+		}
+		if *response.Message != firstMessage {
+			t.Fatalf("Message changed between runs: '%s' vs '%s'", firstMessage, *response.Message)
+		}
+	}
+
+	expected := "Service 'alpha-service' does not exist in namespace 'default' (referenced by rules[0].http.paths[2] (path '/alpha-service')); " +
+		"Service 'zeta-service' does not exist in namespace 'default' " +
+		"(referenced by rules[0].http.paths[0] (path '/zeta-service'), rules[0].http.paths[3] (path '/zeta-service'))"
+	if firstMessage != expected {
+		t.Errorf("Expected message '%s', got '%s'", expected, firstMessage)
+	}
+}
@@ -0,0 +1,173 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	onelog "github.com/francoispqt/onelog"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+)
+
+// validationReport 汇总一次校验中发现的所有问题，
+// 以便在一次拒绝中列出全部缺失的后端，而不是逐个报告。
+type validationReport struct {
+	// violations 记录确定性的校验失败，例如 Service 不存在或端口未声明。
+	violations []string
+	// hostErrors 记录 host call 失败，与“不存在”的结果分开展示。
+	hostErrors []string
+	// warnings 记录不影响准入结果、只需要记录日志的问题。
+	warnings []string
+	// preExisting 记录 UPDATE 前已存在、不阻止本次请求的问题。
+	preExisting []string
+}
+
+// newValidationReport 创建一个空的校验报告。
+func newValidationReport() *validationReport {
+	return &validationReport{}
+}
+
+// addMissingService 记录一个不存在的 Service 及引用它的全部位置。
+func (r *validationReport) addMissingService(namespace, name string, refs []serviceReference) {
+	r.violations = append(r.violations, fmt.Sprintf(
+		"Service '%s' does not exist in namespace '%s' (referenced by %s)",
+		name, namespace, formatReferenceLocations(refs)))
+}
+
+// addMissingPort 记录一个引用了 Service 未声明端口的后端。
+func (r *validationReport) addMissingPort(service *corev1.Service, ref serviceReference) {
+	r.violations = append(r.violations, fmt.Sprintf(
+		"Service '%s' in namespace '%s' does not expose port %s referenced by %s (available ports: %s)",
+		ref.Name, ref.Namespace, formatBackendPort(ref.Port), ref.Location, formatServicePorts(service)))
+}
+
+// addMissingTargetPort 记录一个 targetPort 与 Service 的端口及其 targetPort 都不匹配的引用。
+func (r *validationReport) addMissingTargetPort(service *corev1.Service, ref serviceReference) {
+	r.violations = append(r.violations, fmt.Sprintf(
+		"Service '%s' in namespace '%s' does not expose target port %s referenced by %s (available ports: %s)",
+		ref.Name, ref.Namespace, formatBackendPort(ref.TargetPort), ref.Location, formatServicePorts(service)))
+}
+
+// addViolation 记录一条其他类型的校验失败。
+func (r *validationReport) addViolation(format string, args ...interface{}) {
+	r.violations = append(r.violations, fmt.Sprintf(format, args...))
+}
+
+// addWarning 记录一条只输出日志、不影响准入结果的问题。
+func (r *validationReport) addWarning(format string, args ...interface{}) {
+	r.warnings = append(r.warnings, fmt.Sprintf(format, args...))
+}
+
+// addHostError 记录检查某个被引用对象时发生的 host call 错误，
+// subject 为对象描述，例如 Service 'foo'。
+func (r *validationReport) addHostError(subject, locations string, err error) {
+	if locations == "" {
+		r.hostErrors = append(r.hostErrors, fmt.Sprintf("%s: %s", subject, err))
+		return
+	}
+	r.hostErrors = append(r.hostErrors, fmt.Sprintf(
+		"%s (referenced by %s): %s",
+		subject, locations, err))
+}
+
+// addPreExisting 记录 UPDATE 前已存在的引用问题，这些问题只报告，不阻止请求。
+func (r *validationReport) addPreExisting(problems ...string) {
+	r.preExisting = append(r.preExisting, problems...)
+}
+
+// addPreExistingReport 并入只检查未变化引用得到的报告：其中的违规与 host call 错误作为已存在问题，
+// 只报告、不阻止请求；警告照常保留。
+func (r *validationReport) addPreExistingReport(preExisting *validationReport) {
+	r.addPreExisting(preExisting.violations...)
+	r.addPreExisting(preExisting.hostErrors...)
+	r.warnings = append(r.warnings, preExisting.warnings...)
+}
+
+// addViolations 把 problems 逐条记录为 report 中的校验失败，可以直接作为 checkChangedSeparately 的 check。
+func addViolations(problems []string, report *validationReport) {
+	for _, problem := range problems {
+		report.addViolation("%s", problem)
+	}
+}
+
+// preExistingMessage 生成已存在问题的说明，报告中没有此类问题时返回空字符串。
+func (r *validationReport) preExistingMessage() string {
+	if len(r.preExisting) == 0 {
+		return ""
+	}
+	return "Pre-existing problems not enforced on update: " + strings.Join(r.preExisting, "; ")
+}
+
+// hasViolations 返回报告中是否存在确定性的校验失败（不含 host call 错误）。
+func (r *validationReport) hasViolations() bool {
+	return len(r.violations) > 0
+}
+
+// empty 返回报告中是否没有任何问题。
+func (r *validationReport) empty() bool {
+	return len(r.violations) == 0 && len(r.hostErrors) == 0
+}
+
+// message 生成拒绝时返回给用户的完整信息。
+func (r *validationReport) message() string {
+	parts := make([]string, 0, 2)
+	if len(r.violations) > 0 {
+		parts = append(parts, strings.Join(r.violations, "; "))
+	}
+	if len(r.hostErrors) > 0 {
+		parts = append(parts, "Errors checking referenced objects: "+strings.Join(r.hostErrors, "; "))
+	}
+	if len(r.preExisting) > 0 {
+		parts = append(parts, r.preExistingMessage())
+	}
+	return strings.Join(parts, ". ")
+}
+
+// log 以结构化日志的形式输出报告中的每个问题，供 warn 模式使用。
+func (r *validationReport) log(uid string, meta *metav1.ObjectMeta) {
+	for _, violation := range r.violations {
+		logger.WarnWithFields("object references missing backends", func(e onelog.Entry) {
+			e.String("uid", uid)
+			e.String("name", meta.Name)
+			e.String("namespace", meta.Namespace)
+			e.String("violation", violation)
+		})
+	}
+	for _, hostErr := range r.hostErrors {
+		logger.WarnWithFields("object backends could not be checked", func(e onelog.Entry) {
+			e.String("uid", uid)
+			e.String("name", meta.Name)
+			e.String("namespace", meta.Namespace)
+			e.String("error", hostErr)
+		})
+	}
+}
+
+// logWarnings 以结构化日志的形式输出报告中的警告以及 UPDATE 前已存在的问题。
+func (r *validationReport) logWarnings(uid string, meta *metav1.ObjectMeta) {
+	for _, warning := range r.warnings {
+		logger.WarnWithFields("validation warning", func(e onelog.Entry) {
+			e.String("uid", uid)
+			e.String("name", meta.Name)
+			e.String("namespace", meta.Namespace)
+			e.String("warning", warning)
+		})
+	}
+	for _, problem := range r.preExisting {
+		logger.WarnWithFields("pre-existing problem not enforced on update", func(e onelog.Entry) {
+			e.String("uid", uid)
+			e.String("name", meta.Name)
+			e.String("namespace", meta.Namespace)
+			e.String("problem", problem)
+		})
+	}
+}
+
+// formatReferenceLocations 将多个引用位置拼接为可读字符串。
+func formatReferenceLocations(refs []serviceReference) string {
+	locations := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		locations = append(locations, ref.Location)
+	}
+	return strings.Join(locations, ", ")
+}
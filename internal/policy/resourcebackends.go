@@ -0,0 +1,144 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+)
+
+// ResourceBackendKind 描述允许作为 Ingress backend.resource 的资源类型。
+type ResourceBackendKind struct {
+	// APIVersion 为资源的 API 版本，例如 k8s.example.com/v1，用于 get_resource 查询。
+	APIVersion string `json:"apiVersion"`
+	// Kind 为资源类型，例如 StorageBucket。
+	Kind string `json:"kind"`
+}
+
+// group 返回 APIVersion 中的 API 组，core 组返回空字符串。
+func (k ResourceBackendKind) group() string {
+	if i := strings.Index(k.APIVersion, "/"); i >= 0 {
+		return k.APIVersion[:i]
+	}
+	return ""
+}
+
+// resourceBackendReference 描述 Ingress 中对 backend.resource 的一次引用。
+type resourceBackendReference struct {
+	APIGroup string
+	Kind     string
+	Name     string
+	// Location 描述引用出现的位置，与 serviceReference 相同。
+	Location string
+}
+
+// key 返回用于去重的 group/kind/name。
+func (r resourceBackendReference) key() string {
+	return r.APIGroup + "/" + r.Kind + "/" + r.Name
+}
+
+// describe 返回资源引用的可读描述，例如 StorageBucket 'assets' (apiGroup 'k8s.example.com')。
+func (r resourceBackendReference) describe() string {
+	group := r.APIGroup
+	if group == "" {
+		group = "core"
+	}
+	return fmt.Sprintf("%s '%s' (apiGroup '%s')", r.Kind, r.Name, group)
+}
+
+// extractResourceBackendReferences 按出现顺序收集 Ingress 中所有 backend.resource 引用。
+func extractResourceBackendReferences(ing *networkingv1.Ingress) []resourceBackendReference {
+	if ing == nil || ing.Spec == nil {
+		return nil
+	}
+	var refs []resourceBackendReference
+	add := func(backend *networkingv1.IngressBackend, location string) {
+		if backend == nil || backend.Resource == nil {
+			return
+		}
+		ref := resourceBackendReference{APIGroup: backend.Resource.APIGroup, Location: location}
+		if backend.Resource.Kind != nil {
+			ref.Kind = *backend.Resource.Kind
+		}
+		if backend.Resource.Name != nil {
+			ref.Name = *backend.Resource.Name
+		}
+		refs = append(refs, ref)
+	}
+	add(ing.Spec.DefaultBackend, "defaultBackend")
+	for i, rule := range ing.Spec.Rules {
+		if rule == nil || rule.HTTP == nil {
+			continue
+		}
+		for j, path := range rule.HTTP.Paths {
+			if path == nil {
+				continue
+			}
+			add(path.Backend, formatPathLocation(i, j, rule.Host, path.Path))
+		}
+	}
+	return refs
+}
+
+// checkResourceBackends 校验 backend.resource 引用的资源类型在 allowed_resource_backends 中，
+// 且资源在 Ingress 所在命名空间 namespace 中存在。
+func checkResourceBackends(namespace string, refs []resourceBackendReference, settings Settings, report *validationReport) {
+	var keys []string
+	byKey := map[string][]resourceBackendReference{}
+	for _, ref := range refs {
+		if _, ok := byKey[ref.key()]; !ok {
+			keys = append(keys, ref.key())
+		}
+		byKey[ref.key()] = append(byKey[ref.key()], ref)
+	}
+	// 与 Service 引用相同，按固定顺序查询与报告
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		keyRefs := byKey[key]
+		ref := keyRefs[0]
+		locations := formatResourceLocations(keyRefs)
+		allowed, ok := findAllowedResourceBackend(settings.AllowedResourceBackends, ref)
+		if !ok {
+			report.addViolation("resource backend %s is not an allowed Ingress backend kind (referenced by %s)",
+				ref.describe(), locations)
+			continue
+		}
+		_, err := getResource(getResourceRequest{
+			APIVersion:   allowed.APIVersion,
+			Kind:         allowed.Kind,
+			Namespace:    namespace,
+			Name:         ref.Name,
+			DisableCache: settings.DisableCache,
+		})
+		if errors.Is(err, ErrResourceNotFound) {
+			report.addViolation("resource backend %s does not exist in namespace '%s' (referenced by %s)",
+				ref.describe(), namespace, locations)
+			continue
+		}
+		if err != nil {
+			report.addHostError(fmt.Sprintf("resource backend %s", ref.describe()), locations, err)
+		}
+	}
+}
+
+// findAllowedResourceBackend 在允许列表中查找与引用的 API 组和类型匹配的条目。
+func findAllowedResourceBackend(allowed []ResourceBackendKind, ref resourceBackendReference) (ResourceBackendKind, bool) {
+	for _, kind := range allowed {
+		if kind.Kind == ref.Kind && kind.group() == ref.APIGroup {
+			return kind, true
+		}
+	}
+	return ResourceBackendKind{}, false
+}
+
+// formatResourceLocations 将多个 backend.resource 引用位置拼接为可读字符串。
+func formatResourceLocations(refs []resourceBackendReference) string {
+	locations := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		locations = append(locations, ref.Location)
+	}
+	return strings.Join(locations, ", ")
+}
@@ -0,0 +1,101 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+)
+
+// resourceBackend 构造一个指向 TypedLocalObjectReference 的 backend。
+func resourceBackend(group, kind, name string) *networkingv1.IngressBackend {
+	return &networkingv1.IngressBackend{
+		Resource: &corev1.TypedLocalObjectReference{APIGroup: group, Kind: strPtr(kind), Name: strPtr(name)},
+	}
+}
+
+// resourceIngress 构造一个默认后端与路径后端均为 backend.resource 的 Ingress。
+func resourceIngress(defaultBackend *networkingv1.IngressBackend, pathBackends ...*networkingv1.IngressBackend) *networkingv1.Ingress {
+	paths := make([]*networkingv1.HTTPIngressPath, 0, len(pathBackends))
+	for _, backend := range pathBackends {
+		paths = append(paths, &networkingv1.HTTPIngressPath{Path: "/static", PathType: strPtr("Prefix"), Backend: backend})
+	}
+	return &networkingv1.Ingress{
+		Metadata: &metav1.ObjectMeta{Name: "test-ingress", Namespace: "default"},
+		Spec: &networkingv1.IngressSpec{
+			DefaultBackend: defaultBackend,
+			Rules: []*networkingv1.IngressRule{{
+				Host: "static.example.com",
+				HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths},
+			}},
+		},
+	}
+}
+
+func resourceBackendSettings() Settings {
+	return Settings{
+		EnforceServiceExists:     true,
+		ValidateResourceBackends: true,
+		AllowedResourceBackends: []ResourceBackendKind{
+			{APIVersion: "k8s.example.com/v1", Kind: "StorageBucket"},
+		},
+	}
+}
+
+func TestAllowedResourceBackendIsAccepted(t *testing.T) {
+	host.Client = newMockWapcClient(map[string]string{
+		mockResourceKey("StorageBucket", "default", "assets"): `{"apiVersion":"k8s.example.com/v1","kind":"StorageBucket",` +
+			`"metadata":{"name":"assets","namespace":"default"}}`,
+	})
+	settings := resourceBackendSettings()
+	ingress := resourceIngress(nil, resourceBackend("k8s.example.com", "StorageBucket", "assets"))
+	if response := validateWithClient(t, ingress, &settings); !response.Accepted {
+		t.Errorf("Unexpected rejection: %s", *response.Message)
+	}
+}
+
+func TestInvalidResourceBackendsAreRejected(t *testing.T) {
+	setupTestEnv()
+	settings := resourceBackendSettings()
+	ingress := resourceIngress(
+		resourceBackend("", "ConfigMap", "static-content"),
+		resourceBackend("k8s.example.com", "StorageBucket", "missing-bucket"),
+		resourceBackend("other.example.com", "StorageBucket", "assets"),
+	)
+	response := validateWithClient(t, ingress, &settings)
+	if response.Accepted {
+		t.Fatal("Expected rejection for invalid resource backends")
+	}
+	for _, expected := range []string{
+		"resource backend ConfigMap 'static-content' (apiGroup 'core') is not an allowed Ingress backend kind (referenced by defaultBackend)",
+		"resource backend StorageBucket 'missing-bucket' (apiGroup 'k8s.example.com') does not exist in namespace 'default'",
+		"resource backend StorageBucket 'assets' (apiGroup 'other.example.com') is not an allowed Ingress backend kind",
+	} {
+		if !strings.Contains(*response.Message, expected) {
+			t.Errorf("Expected message to contain '%s', got '%s'", expected, *response.Message)
+		}
+	}
+}
+
+func TestResourceBackendsIgnoredWhenDisabled(t *testing.T) {
+	setupTestEnv()
+	settings := Settings{EnforceServiceExists: true}
+	ingress := resourceIngress(resourceBackend("", "ConfigMap", "static-content"))
+	if response := validateWithClient(t, ingress, &settings); !response.Accepted {
+		t.Errorf("Unexpected rejection: %s", *response.Message)
+	}
+}
+
+func TestAllowedResourceBackendsSettings(t *testing.T) {
+	settings := Settings{AllowedResourceBackends: []ResourceBackendKind{{APIVersion: "k8s.example.com/v1", Kind: "StorageBucket"}}}
+	if valid, err := settings.Valid(); valid || err == nil {
+		t.Error("Expected allowed_resource_backends without validate_resource_backends to be rejected")
+	}
+	settings.ValidateResourceBackends = true
+	settings.AllowedResourceBackends = append(settings.AllowedResourceBackends, ResourceBackendKind{Kind: "Bucket"})
+	if valid, err := settings.Valid(); valid || err == nil {
+		t.Error("Expected an entry without apiVersion to be rejected")
+	}
+}
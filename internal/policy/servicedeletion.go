@@ -0,0 +1,111 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	onelog "github.com/francoispqt/onelog"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+	kubewarden "github.com/kubewarden/policy-sdk-go"
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+// ingressList 对应 list_resources_by_namespace 返回的 IngressList。
+type ingressList struct {
+	Items []*networkingv1.Ingress `json:"items"`
+}
+
+// validateServiceDeletion 在删除 Service 时检查命名空间内是否仍有 Ingress 引用它，
+// 避免留下指向不存在后端的 Ingress。
+func validateServiceDeletion(validationRequest *kubewarden_protocol.ValidationRequest, settings Settings) ([]byte, error) {
+	if validationRequest.Request.Operation != operationDelete ||
+		!settings.DenyReferencedServiceDeletion || !settings.IsEnforcementEnabled() {
+		return kubewarden.AcceptRequest()
+	}
+
+	// DELETE 请求中被删除的对象位于 OldObject
+	service, err := getService(validationRequest.Request.OldObject)
+	if err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(fmt.Sprintf("Cannot decode Service: %s", err)),
+			kubewarden.Code(httpBadRequestStatusCode))
+	}
+
+	logger.DebugWithFields("validating service deletion", func(e onelog.Entry) {
+		e.String("name", service.Metadata.Name)
+		e.String("namespace", service.Metadata.Namespace)
+	})
+
+	if exempt, response, err := handleExemptions(service.Metadata, settings); exempt {
+		return response, err
+	}
+
+	report := newValidationReport()
+	checkServiceStillReferenced(settings, service.Metadata.Namespace, service.Metadata.Name, report)
+	return respondWithReport(settings, report, validationRequest.Request.Uid, service.Metadata)
+}
+
+// checkServiceStillReferenced 列出命名空间内的 Ingress，将仍引用该 Service 的 Ingress 写入 report。
+func checkServiceStillReferenced(settings Settings, namespace, name string, report *validationReport) {
+	ingresses, err := listIngresses(namespace)
+	if err != nil {
+		report.addHostError(fmt.Sprintf("Ingresses in namespace '%s'", namespace), "", err)
+		return
+	}
+
+	var referencing []string
+	for _, ing := range ingresses {
+		if ing == nil || ing.Metadata == nil {
+			continue
+		}
+		var locations []string
+		for _, ref := range extractServiceReferences(ing, settings) {
+			if ref.Name == name {
+				locations = append(locations, ref.Location)
+			}
+		}
+		if len(locations) > 0 {
+			referencing = append(referencing,
+				fmt.Sprintf("Ingress '%s' (%s)", ing.Metadata.Name, strings.Join(locations, ", ")))
+		}
+	}
+	if len(referencing) > 0 {
+		report.addViolation("Service '%s' in namespace '%s' cannot be deleted because it is still referenced by %s",
+			name, namespace, strings.Join(referencing, "; "))
+	}
+}
+
+// listIngresses 通过 host capabilities 列出命名空间内的全部 Ingress。
+func listIngresses(namespace string) ([]*networkingv1.Ingress, error) {
+	respBytes, err := listResourcesByNamespace(listResourcesByNamespaceRequest{
+		APIVersion: "networking.k8s.io/v1",
+		Kind:       "Ingress",
+		Namespace:  namespace,
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := ingressList{}
+	if err := json.Unmarshal(respBytes, &list); err != nil {
+		return nil, fmt.Errorf("cannot decode IngressList: %w", err)
+	}
+	return list.Items, nil
+}
+
+// getService 从 RAW JSON 中解析出 Service 对象。
+func getService(rawJSON json.RawMessage) (*corev1.Service, error) {
+	if len(rawJSON) == 0 {
+		return nil, errors.New("empty service object")
+	}
+	service := &corev1.Service{}
+	if err := json.Unmarshal(rawJSON, service); err != nil {
+		return nil, err
+	}
+	if service.Metadata == nil {
+		return nil, errors.New("service metadata is missing")
+	}
+	return service, nil
+}
@@ -0,0 +1,146 @@
+package policy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+// ingressFixtures 返回 default 命名空间中引用 my-service 的两个 Ingress，
+// 以及 sandbox 命名空间中同名 Service 的引用。
+func ingressFixtures() map[string]string {
+	return map[string]string{
+		mockResourceKey("Ingress", "default", "web"): `{"kind":"Ingress","apiVersion":"networking.k8s.io/v1",` +
+			`"metadata":{"name":"web","namespace":"default"},` +
+			`"spec":{"defaultBackend":{"service":{"name":"my-service","port":{"number":80}}}}}`,
+		mockResourceKey("Ingress", "default", "api"): `{"kind":"Ingress","apiVersion":"networking.k8s.io/v1",` +
+			`"metadata":{"name":"api","namespace":"default"},"spec":{"rules":[{"host":"api.example.com","http":{"paths":[` +
+			`{"path":"/","pathType":"Prefix","backend":{"service":{"name":"other-service","port":{"number":80}}}},` +
+			`{"path":"/v1","pathType":"Prefix","backend":{"service":{"name":"my-service","port":{"name":"http"}}}}]}}]}}`,
+		mockResourceKey("Ingress", "sandbox", "orphan"): `{"kind":"Ingress","apiVersion":"networking.k8s.io/v1",` +
+			`"metadata":{"name":"orphan","namespace":"sandbox"},` +
+			`"spec":{"defaultBackend":{"service":{"name":"sandbox-service","port":{"number":80}}}}}`,
+	}
+}
+
+// validateServiceDelete 以 DELETE 操作构造针对 Service 的请求并执行 validate。
+func validateServiceDelete(t *testing.T, namespace, name string, settings *Settings) kubewarden_protocol.ValidationResponse {
+	t.Helper()
+	oldObject, err := json.Marshal(corev1.Service{
+		Metadata: &metav1.ObjectMeta{Name: name, Namespace: namespace},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	settingsRaw, err := json.Marshal(settings)
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	payload, err := json.Marshal(kubewarden_protocol.ValidationRequest{
+		Request: kubewarden_protocol.KubernetesAdmissionRequest{
+			Uid:       "test-uid",
+			Kind:      kubewarden_protocol.GroupVersionKind{Kind: serviceKind},
+			Operation: operationDelete,
+			OldObject: oldObject,
+		},
+		Settings: settingsRaw,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	responsePayload, err := Validate(payload)
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	var response kubewarden_protocol.ValidationResponse
+	if err = json.Unmarshal(responsePayload, &response); err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	return response
+}
+
+func TestDeleteReferencedServiceIsRejected(t *testing.T) {
+	host.Client = newMockWapcClient(ingressFixtures())
+	settings := Settings{EnforceServiceExists: true, DenyReferencedServiceDeletion: true}
+	response := validateServiceDelete(t, "default", "my-service", &settings)
+	if response.Accepted {
+		t.Fatal("Expected rejection when deleting a referenced Service")
+	}
+	expected := "Service 'my-service' in namespace 'default' cannot be deleted because it is still referenced by " +
+		"Ingress 'api' (rules[0].http.paths[1] (host 'api.example.com', path '/v1')); Ingress 'web' (defaultBackend)"
+	if *response.Message != expected {
+		t.Errorf("Expected message '%s', got '%s'", expected, *response.Message)
+	}
+}
+
+func TestDeleteServiceWithoutReferences(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		service   string
+		settings  Settings
+	}{
+		{
+			name:      "unreferenced service",
+			namespace: "default",
+			service:   "unused-service",
+			settings:  Settings{EnforceServiceExists: true, DenyReferencedServiceDeletion: true},
+		},
+		{
+			name:      "same name referenced only in another namespace",
+			namespace: "default",
+			service:   "sandbox-service",
+			settings:  Settings{EnforceServiceExists: true, DenyReferencedServiceDeletion: true},
+		},
+		{
+			name:      "check disabled",
+			namespace: "default",
+			service:   "my-service",
+			settings:  Settings{EnforceServiceExists: true},
+		},
+		{
+			name:      "exempt namespace",
+			namespace: "default",
+			service:   "my-service",
+			settings: Settings{
+				EnforceServiceExists:          true,
+				DenyReferencedServiceDeletion: true,
+				ExemptNamespaces:              []string{"default"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host.Client = newMockWapcClient(ingressFixtures())
+			response := validateServiceDelete(t, tt.namespace, tt.service, &tt.settings)
+			if !response.Accepted {
+				t.Errorf("Unexpected rejection: %s", *response.Message)
+			}
+		})
+	}
+}
+
+func TestDeleteServiceListFailureFollowsFailurePolicy(t *testing.T) {
+	client := newMockWapcClient(ingressFixtures())
+	client.errors["Ingress"] = "ingresses.networking.k8s.io is forbidden: User cannot list resource"
+	host.Client = client
+
+	settings := Settings{EnforceServiceExists: true, DenyReferencedServiceDeletion: true}
+	response := validateServiceDelete(t, "default", "my-service", &settings)
+	if response.Accepted {
+		t.Fatal("Expected rejection with failure_policy=fail")
+	}
+	if !strings.Contains(*response.Message, "Ingresses in namespace 'default': host call failed (access forbidden)") {
+		t.Errorf("Unexpected message: %s", *response.Message)
+	}
+
+	settings.FailurePolicy = failurePolicyIgnore
+	if response = validateServiceDelete(t, "default", "my-service", &settings); !response.Accepted {
+		t.Errorf("Expected acceptance with failure_policy=ignore, got: %s", *response.Message)
+	}
+}
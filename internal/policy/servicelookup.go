@@ -0,0 +1,128 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	onelog "github.com/francoispqt/onelog"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+)
+
+// 支持的 lookup_strategy 取值。
+const (
+	// lookupStrategyGet 对每个 Service 调用一次 get_resource。
+	lookupStrategyGet = "get"
+	// lookupStrategyList 每个命名空间调用一次 list_resources_by_namespace，在内存中建立索引。
+	lookupStrategyList = "list"
+)
+
+// serviceList 对应 list_resources_by_namespace 返回的 ServiceList。
+type serviceList struct {
+	Items []*corev1.Service `json:"items"`
+}
+
+// serviceNamespaceIndex 缓存一个命名空间的 Service 列表结果。
+type serviceNamespaceIndex struct {
+	services map[string]*corev1.Service
+	// err 为列出 Service 时发生的错误，非 nil 时该命名空间的所有查询都返回此错误。
+	err error
+	// fallback 为 true 表示无权列出 Service，回退到逐个 get_resource。
+	fallback bool
+}
+
+// serviceLookupResult 是一次 get_resource 查询的结果。
+type serviceLookupResult struct {
+	service *corev1.Service
+	found   bool
+	err     error
+}
+
+// serviceLookup 在一次请求内回答 Service 的存在性查询，同一个 Service 只查询一次。
+// lookup_strategy 为 list 时每个命名空间只列出一次 Service。
+type serviceLookup struct {
+	settings Settings
+	indexes  map[string]*serviceNamespaceIndex
+	// results 缓存逐个 get_resource 查询的结果，键为 namespace/name。
+	results map[string]serviceLookupResult
+}
+
+// newServiceLookup 创建一次请求使用的 serviceLookup。
+func newServiceLookup(settings Settings) *serviceLookup {
+	return &serviceLookup{
+		settings: settings,
+		indexes:  map[string]*serviceNamespaceIndex{},
+		results:  map[string]serviceLookupResult{},
+	}
+}
+
+// get 返回命名空间中的 Service；不存在时第二个返回值为 false。
+func (l *serviceLookup) get(namespace, name string) (*corev1.Service, bool, error) {
+	if l.settings.LookupStrategy == lookupStrategyList {
+		index := l.namespaceIndex(namespace)
+		if !index.fallback {
+			if index.err != nil {
+				return nil, false, index.err
+			}
+			service, ok := index.services[name]
+			return service, ok, nil
+		}
+	}
+	return l.getResource(namespace, name)
+}
+
+// getResource 通过 get_resource 查询单个 Service，并缓存结果供同一请求内的后续查询使用。
+func (l *serviceLookup) getResource(namespace, name string) (*corev1.Service, bool, error) {
+	key := namespace + "/" + name
+	if result, ok := l.results[key]; ok {
+		return result.service, result.found, result.err
+	}
+	service, found, err := serviceExists(namespace, l.settings, name)
+	l.results[key] = serviceLookupResult{service: service, found: found, err: err}
+	return service, found, err
+}
+
+// namespaceIndex 返回命名空间的 Service 索引，第一次访问时列出 Service。
+func (l *serviceLookup) namespaceIndex(namespace string) *serviceNamespaceIndex {
+	if index, ok := l.indexes[namespace]; ok {
+		return index
+	}
+	index := &serviceNamespaceIndex{}
+	services, err := listServices(namespace)
+	switch {
+	case errors.Is(err, ErrForbidden):
+		logger.DebugWithFields("cannot list services, falling back to per-name lookups", func(e onelog.Entry) {
+			e.String("namespace", namespace)
+			e.String("error", err.Error())
+		})
+		index.fallback = true
+	case err != nil:
+		index.err = err
+	default:
+		index.services = make(map[string]*corev1.Service, len(services))
+		for _, service := range services {
+			if service != nil && service.Metadata != nil {
+				index.services[service.Metadata.Name] = service
+			}
+		}
+	}
+	l.indexes[namespace] = index
+	return index
+}
+
+// listServices 通过 host capabilities 列出命名空间内的全部 Service。
+func listServices(namespace string) ([]*corev1.Service, error) {
+	respBytes, err := listResourcesByNamespace(listResourcesByNamespaceRequest{
+		APIVersion: "v1",
+		Kind:       "Service",
+		Namespace:  namespace,
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := serviceList{}
+	if err := json.Unmarshal(respBytes, &list); err != nil {
+		return nil, fmt.Errorf("cannot decode ServiceList: %w", err)
+	}
+	return list.Items, nil
+}
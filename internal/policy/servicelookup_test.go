@@ -0,0 +1,93 @@
+package policy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+)
+
+// countingWapcClient 记录每种 host call 操作及查询的资源类型的调用次数。
+type countingWapcClient struct {
+	*mockWapcClient
+	calls map[string]int
+}
+
+func (c *countingWapcClient) HostCall(binding, namespace, operation string, payload []byte) ([]byte, error) {
+	req := map[string]interface{}{}
+	if err := json.Unmarshal(payload, &req); err == nil {
+		kind, _ := req["kind"].(string)
+		c.calls[operation+"/"+kind]++
+	}
+	return c.mockWapcClient.HostCall(binding, namespace, operation, payload)
+}
+
+// lookupIngress 构造一个引用多个 Service 的 Ingress。
+func lookupIngress() *networkingv1.Ingress {
+	ingress := pathIngress("my-service", "non-existent-service", "external-service", "my-service")
+	ingress.Spec.Rules[0].HTTP.Paths[3].Backend.Service.Port = &networkingv1.ServiceBackendPort{Name: "grpc"}
+	return ingress
+}
+
+func TestListLookupStrategyUsesSingleListCall(t *testing.T) {
+	client := &countingWapcClient{mockWapcClient: newMockWapcClient(nil), calls: map[string]int{}}
+	host.Client = client
+	settings := Settings{EnforceServiceExists: true, LookupStrategy: lookupStrategyList}
+	listResponse := validateWithClient(t, lookupIngress(), &settings)
+
+	if client.calls["list_resources_by_namespace/Service"] != 1 {
+		t.Errorf("Expected a single Service list call, got %v", client.calls)
+	}
+	if client.calls["get_resource/Service"] != 0 {
+		t.Errorf("Expected no per-name Service lookups, got %v", client.calls)
+	}
+
+	// 与默认的逐个查询给出相同的结果
+	setupTestEnv()
+	settings.LookupStrategy = lookupStrategyGet
+	getResponse := validateWithClient(t, lookupIngress(), &settings)
+	if listResponse.Accepted || getResponse.Accepted {
+		t.Fatal("Expected both strategies to reject the Ingress")
+	}
+	if *listResponse.Message != *getResponse.Message {
+		t.Errorf("Expected identical messages, got '%s' and '%s'", *listResponse.Message, *getResponse.Message)
+	}
+}
+
+func TestListLookupStrategyFallsBackWhenForbidden(t *testing.T) {
+	client := &countingWapcClient{mockWapcClient: newMockWapcClient(nil), calls: map[string]int{}}
+	client.errors["Service"] = "services is forbidden: User cannot list resource \"services\""
+	host.Client = client
+	settings := Settings{EnforceServiceExists: true, LookupStrategy: lookupStrategyList}
+	response := validateWithClient(t, lookupIngress(), &settings)
+
+	if client.calls["get_resource/Service"] != 3 {
+		t.Errorf("Expected per-name lookups for the three Services, got %v", client.calls)
+	}
+	if response.Accepted || !strings.Contains(*response.Message, "Service 'non-existent-service' does not exist") {
+		t.Errorf("Unexpected response after fallback: %v", response.Message)
+	}
+}
+
+func TestListLookupStrategyReportsTransientErrors(t *testing.T) {
+	client := newMockWapcClient(nil)
+	client.errors["Service"] = "connection refused"
+	host.Client = client
+	settings := Settings{EnforceServiceExists: true, LookupStrategy: lookupStrategyList}
+	response := validateWithClient(t, lookupIngress(), &settings)
+	if response.Accepted {
+		t.Fatal("Expected rejection with failure_policy=fail")
+	}
+	if !strings.Contains(*response.Message, "Service 'my-service' (referenced by") ||
+		!strings.Contains(*response.Message, "host call failed (transient error)") {
+		t.Errorf("Unexpected message: %s", *response.Message)
+	}
+}
+
+func TestInvalidLookupStrategy(t *testing.T) {
+	settings := Settings{LookupStrategy: "watch"}
+	if valid, err := settings.Valid(); valid || err == nil {
+		t.Error("Expected lookup_strategy 'watch' to be rejected")
+	}
+}
@@ -0,0 +1,274 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+	kubewarden "github.com/kubewarden/policy-sdk-go"
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+// ErrEmptySignatures 表示嵌套设置中的签名列表为空。
+var ErrEmptySignatures = errors.New("nested settings contains empty signatures")
+
+const defaultEnforceServiceExists = true
+const defaultDisableCache = false
+
+// 支持的 enforcement_mode 取值。
+const (
+	// enforcementModeDeny 发现问题时拒绝请求。
+	enforcementModeDeny = "deny"
+	// enforcementModeWarn 完整执行校验，但只记录日志并放行请求。
+	enforcementModeWarn = "warn"
+	// enforcementModeOff 跳过所有校验。
+	enforcementModeOff = "off"
+)
+
+// 支持的 failure_policy 取值。
+const (
+	// failurePolicyFail 在无法校验（host call 失败）时拒绝请求。
+	failurePolicyFail = "fail"
+	// failurePolicyIgnore 在无法校验时放行请求。
+	failurePolicyIgnore = "ignore"
+)
+
+// Settings 定义了策略中的所有可配置项。
+type Settings struct {
+	// 是否强制校验 Ingress 引用的 Service 是否存在。
+	// 未设置 EnforcementMode 时，true 等价于 deny，false 等价于 off。
+	EnforceServiceExists bool `json:"enforce_service_exists"`
+	// 校验模式：deny、warn 或 off，优先级高于 EnforceServiceExists。
+	EnforcementMode string `json:"enforcement_mode"`
+	// host call 失败（无权限、超时等）时的处理策略：fail 或 ignore，默认 fail。
+	FailurePolicy string `json:"failure_policy"`
+	// 是否禁用 Host Capabilities 的缓存。
+	DisableCache bool `json:"disable_cache"`
+	// 是否校验 spec.ingressClassName 指向存在的 IngressClass。
+	ValidateIngressClass bool `json:"validate_ingress_class"`
+	// 未指定 IngressClass 且集群没有默认 IngressClass 时的处理方式：deny（默认）或 warn。
+	MissingDefaultIngressClass string `json:"missing_default_ingress_class"`
+	// 按命名空间允许使用的 IngressClass 列表，键 "*" 适用于未单独配置的命名空间。
+	AllowedIngressClasses map[string][]string `json:"allowed_ingress_classes"`
+	// 是否校验 spec.tls 引用的 Secret 存在且为合法的 kubernetes.io/tls 类型。
+	EnforceTLSSecretExists bool `json:"enforce_tls_secret_exists"`
+	// 是否解析 TLS 证书并校验主机覆盖、有效期与证书链，需要同时开启 EnforceTLSSecretExists。
+	VerifyTLSCertificates bool `json:"verify_tls_certificates"`
+	// 证书剩余有效期的最小天数，0 表示只检查是否已过期。
+	MinCertValidityDays int `json:"min_cert_validity_days"`
+	// 跳过校验的命名空间列表。
+	ExemptNamespaces []string `json:"exempt_namespaces"`
+	// 命名空间标签选择器，命中的命名空间跳过校验。
+	ExemptNamespaceSelector *metav1.LabelSelector `json:"exempt_namespace_selector"`
+	// 是否要求引用的 Service 端口存在就绪端点：deny、warn 或 off（默认）。
+	RequireReadyEndpoints string `json:"require_ready_endpoints"`
+	// 是否校验 Ingress 中的 backend.resource 引用。
+	ValidateResourceBackends bool `json:"validate_resource_backends"`
+	// 允许作为 backend.resource 的资源类型，未列出的类型会被拒绝。
+	AllowedResourceBackends []ResourceBackendKind `json:"allowed_resource_backends"`
+	// UPDATE 时是否只强制校验新增的后端引用，已存在的问题只报告不阻止。
+	ValidateOnlyChangedBackends bool `json:"validate_only_changed_backends"`
+	// 声明命名空间允许主机 glob 的 Namespace 注解，例如 ingress.example.com/allowed-hosts。
+	AllowedHostsAnnotation string `json:"allowed_hosts_annotation"`
+	// 命名空间没有设置注解时使用的集群级允许主机 glob 列表。
+	AllowedHosts []string `json:"allowed_hosts"`
+	// 是否检查与集群内其他 Ingress 的 host + path 冲突。
+	DetectHostPathCollisions bool `json:"detect_host_path_collisions"`
+	// 是否根据 Service 规范化 Ingress 后端端口并返回修改后的对象，需要以 mutating 模式部署策略。
+	NormalizeBackendPorts bool `json:"normalize_backend_ports"`
+	// Service 查询方式：get（默认，逐个 get_resource）或 list（每个命名空间列出一次）。
+	LookupStrategy string `json:"lookup_strategy"`
+	// 额外禁止的 ExternalName 目标（主机名 glob、IP 或 CIDR），与内置的禁止列表合并。
+	DeniedExternalNameDestinations []string `json:"denied_external_name_destinations"`
+	// 允许的 ExternalName 目标（主机名 glob、IP 或 CIDR），优先于禁止列表；非空时只允许列出的目标。
+	AllowedExternalNameDestinations []string `json:"allowed_external_name_destinations"`
+	// 启用注解引用校验的 Ingress 控制器，例如 ingress-nginx；注解中引用的 Service 与 Secret 必须存在。
+	AnnotationReferenceControllers []string `json:"annotation_reference_controllers"`
+	// 是否拒绝删除仍被同命名空间 Ingress 引用的 Service。
+	DenyReferencedServiceDeletion bool `json:"deny_referenced_service_deletion"`
+	// 是否允许 Ingress 通过 ingressOptOutKey 标签或注解自行豁免。
+	AllowIngressOptOut bool `json:"allow_ingress_opt_out"`
+}
+
+// IncomingSettings matches the structure of the settings provided by kwctl run.
+// 每个签名保持原始 JSON，以便像扁平设置一样在默认值之上解析。
+type IncomingSettings struct {
+	Signatures []json.RawMessage `json:"signatures"`
+}
+
+// defaultSettings 返回未提供设置时使用的默认值，用户设置在其之上解析，未出现的字段保持默认值。
+func defaultSettings() Settings {
+	return Settings{
+		EnforceServiceExists: defaultEnforceServiceExists,
+		DisableCache:         defaultDisableCache,
+	}
+}
+
+// tryUnmarshalFlatSettings 尝试将设置解析为扁平结构。
+func tryUnmarshalFlatSettings(raw []byte) (*Settings, error) {
+	settings := defaultSettings()
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// tryUnmarshalNestedSettings 尝试将设置解析为嵌套结构。
+func tryUnmarshalNestedSettings(raw []byte) (*Settings, error) {
+	var nested IncomingSettings
+	if err := json.Unmarshal(raw, &nested); err != nil {
+		return nil, err
+	}
+	if len(nested.Signatures) == 0 {
+		return nil, ErrEmptySignatures
+	}
+	return tryUnmarshalFlatSettings(nested.Signatures[0])
+}
+
+// NewSettingsFromValidationReq 从 ValidationRequest 中提取设置，
+// 用户未提供的字段（例如只开启 validate_ingress_class 时的 enforce_service_exists）使用默认值。
+func NewSettingsFromValidationReq(validationReq *kubewarden_protocol.ValidationRequest) (Settings, error) {
+	// 1. 使用默认值初始化
+	settings := defaultSettings()
+
+	// 如果没有自定义设置，直接返回默认值
+	if len(validationReq.Settings) == 0 {
+		return settings, nil
+	}
+
+	// 2. 尝试以嵌套格式解析；扁平解析会忽略 signatures 字段，因此需要先尝试嵌套格式
+	if nestedSettings, err := tryUnmarshalNestedSettings(validationReq.Settings); err == nil && nestedSettings != nil {
+		return *nestedSettings, nil
+	}
+
+	// 3. 以扁平格式解析（向后兼容）
+	flatSettings, err := tryUnmarshalFlatSettings(validationReq.Settings)
+	if err != nil {
+		return Settings{}, fmt.Errorf("cannot parse settings JSON: %w", err)
+	}
+	return *flatSettings, nil
+}
+
+// Valid 对 Settings 本身做合法性校验。
+func (s *Settings) Valid() (bool, error) {
+	switch s.EnforcementMode {
+	case "", enforcementModeDeny, enforcementModeWarn, enforcementModeOff:
+	default:
+		return false, fmt.Errorf("invalid enforcement_mode '%s': must be one of %s, %s, %s",
+			s.EnforcementMode, enforcementModeDeny, enforcementModeWarn, enforcementModeOff)
+	}
+	switch s.FailurePolicy {
+	case "", failurePolicyFail, failurePolicyIgnore:
+	default:
+		return false, fmt.Errorf("invalid failure_policy '%s': must be one of %s, %s",
+			s.FailurePolicy, failurePolicyFail, failurePolicyIgnore)
+	}
+	switch s.MissingDefaultIngressClass {
+	case "", enforcementModeDeny, enforcementModeWarn:
+	default:
+		return false, fmt.Errorf("invalid missing_default_ingress_class '%s': must be one of %s, %s",
+			s.MissingDefaultIngressClass, enforcementModeDeny, enforcementModeWarn)
+	}
+	switch s.LookupStrategy {
+	case "", lookupStrategyGet, lookupStrategyList:
+	default:
+		return false, fmt.Errorf("invalid lookup_strategy '%s': must be one of %s, %s",
+			s.LookupStrategy, lookupStrategyGet, lookupStrategyList)
+	}
+	switch s.RequireReadyEndpoints {
+	case "", enforcementModeDeny, enforcementModeWarn, enforcementModeOff:
+	default:
+		return false, fmt.Errorf("invalid require_ready_endpoints '%s': must be one of %s, %s, %s",
+			s.RequireReadyEndpoints, enforcementModeDeny, enforcementModeWarn, enforcementModeOff)
+	}
+	if s.MinCertValidityDays < 0 {
+		return false, errors.New("min_cert_validity_days cannot be negative")
+	}
+	if s.VerifyTLSCertificates && !s.EnforceTLSSecretExists {
+		return false, errors.New("verify_tls_certificates requires enforce_tls_secret_exists to be enabled")
+	}
+	if len(s.AllowedResourceBackends) > 0 && !s.ValidateResourceBackends {
+		return false, errors.New("allowed_resource_backends requires validate_resource_backends to be enabled")
+	}
+	for i, kind := range s.AllowedResourceBackends {
+		if kind.APIVersion == "" || kind.Kind == "" {
+			return false, fmt.Errorf("allowed_resource_backends[%d] must set both apiVersion and kind", i)
+		}
+	}
+	if _, err := hostMatchesAny("", s.AllowedHosts); err != nil {
+		return false, fmt.Errorf("invalid allowed_hosts pattern %w", err)
+	}
+	if err := validateAnnotationControllerSettings(s); err != nil {
+		return false, err
+	}
+	if err := validateExternalNameDestinationSettings(s); err != nil {
+		return false, err
+	}
+	for _, ns := range s.ExemptNamespaces {
+		if ns == "" {
+			return false, errors.New("exempt_namespaces cannot contain an empty namespace")
+		}
+	}
+	if s.ExemptNamespaceSelector != nil {
+		if err := validateLabelSelector(s.ExemptNamespaceSelector); err != nil {
+			return false, fmt.Errorf("invalid exempt_namespace_selector: %w", err)
+		}
+	}
+	return true, nil
+}
+
+// EffectiveEnforcementMode 返回最终生效的校验模式。
+// 未显式设置 enforcement_mode 时，根据 enforce_service_exists 推导。
+func (s *Settings) EffectiveEnforcementMode() string {
+	if s.EnforcementMode != "" {
+		return s.EnforcementMode
+	}
+	if s.EnforceServiceExists {
+		return enforcementModeDeny
+	}
+	return enforcementModeOff
+}
+
+// IsReadyEndpointsCheckEnabled 返回是否需要检查 Service 的就绪端点。
+func (s *Settings) IsReadyEndpointsCheckEnabled() bool {
+	return s.RequireReadyEndpoints == enforcementModeDeny || s.RequireReadyEndpoints == enforcementModeWarn
+}
+
+// EffectiveFailurePolicy 返回最终生效的 failure_policy，默认为 fail。
+func (s *Settings) EffectiveFailurePolicy() string {
+	if s.FailurePolicy == "" {
+		return failurePolicyFail
+	}
+	return s.FailurePolicy
+}
+
+// IsEnforcementEnabled 返回最终是否要启用 Service 存在校验（deny 或 warn 模式）。
+func (s *Settings) IsEnforcementEnabled() bool {
+	return s.EffectiveEnforcementMode() != enforcementModeOff
+}
+
+// ValidateSettings 由 Kubewarden 在策略加载时调用，
+// 只负责反序列化并校验 Settings，不做默认值合并。
+func ValidateSettings(payload []byte) ([]byte, error) {
+	var settings Settings
+	if err := json.Unmarshal(payload, &settings); err != nil {
+		return kubewarden.RejectSettings(
+			kubewarden.Message(fmt.Sprintf("Provided settings are not valid: %v", err)),
+		)
+	}
+
+	valid, err := settings.Valid()
+	if err != nil {
+		return kubewarden.RejectSettings(
+			kubewarden.Message(fmt.Sprintf("Settings validation failed: %v", err)),
+		)
+	}
+	if !valid {
+		return kubewarden.RejectSettings(
+			kubewarden.Message("Settings validation failed"),
+		)
+	}
+
+	return kubewarden.AcceptSettings()
+}
@@ -0,0 +1,221 @@
+package policy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+// helper: 构造一个 ValidationRequest，内嵌 rawSettings
+func makeValidationRequest(rawSettings []byte) *kubewarden_protocol.ValidationRequest {
+	return &kubewarden_protocol.ValidationRequest{
+		Settings: rawSettings,
+		// 其他字段在 Settings 测试中无需关心
+	}
+}
+
+// 测试：未提供配置时，默认 EnforceServiceExists == true。
+func TestNewSettingsWithNoValueProvided(t *testing.T) {
+	// 模拟用户未在 CRD 中填 settings（empty payload）
+	vr := makeValidationRequest([]byte(``))
+
+	settings, err := NewSettingsFromValidationReq(vr)
+	if err != nil {
+		t.Fatalf("Unexpected error creating settings: %v", err)
+	}
+
+	if !settings.EnforceServiceExists {
+		t.Errorf("Expected EnforceServiceExists to default to true, got false")
+	}
+	if !settings.IsEnforcementEnabled() {
+		t.Errorf("Expected IsEnforcementEnabled() to return true by default")
+	}
+	isValid, validErr := settings.Valid()
+	if !isValid || validErr != nil {
+		t.Errorf("Expected settings.Valid() to pass, got valid=%v, err=%v", isValid, validErr)
+	}
+}
+
+// 测试：提供 explicit JSON 配置（false），应覆盖默认值。
+func TestNewSettingsWithExplicitFalse(t *testing.T) {
+	vr := makeValidationRequest([]byte(`{"enforce_service_exists": false}`))
+
+	settings, err := NewSettingsFromValidationReq(vr)
+	if err != nil {
+		t.Fatalf("Unexpected error creating settings: %v", err)
+	}
+
+	if settings.EnforceServiceExists {
+		t.Errorf("Expected EnforceServiceExists to be false when explicitly set, got true")
+	}
+	if settings.IsEnforcementEnabled() {
+		t.Errorf("Expected IsEnforcementEnabled() to return false when disabled")
+	}
+	isValid, validErr := settings.Valid()
+	if !isValid || validErr != nil {
+		t.Errorf("Expected settings.Valid() to pass, got valid=%v, err=%v", isValid, validErr)
+	}
+}
+
+// 测试：只设置其他选项时，未出现的 enforce_service_exists 保持默认值 true。
+func TestNewSettingsKeepsDefaultsForOmittedFields(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          string
+		expectedMode string
+	}{
+		{name: "opt-in check only", raw: `{"validate_ingress_class": true}`, expectedMode: enforcementModeDeny},
+		{name: "enforcement_mode only", raw: `{"enforcement_mode": "warn"}`, expectedMode: enforcementModeWarn},
+		{name: "nested settings", raw: `{"signatures": [{"validate_ingress_class": true}]}`, expectedMode: enforcementModeDeny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, err := NewSettingsFromValidationReq(makeValidationRequest([]byte(tt.raw)))
+			if err != nil {
+				t.Fatalf("Unexpected error creating settings: %v", err)
+			}
+			if !settings.EnforceServiceExists {
+				t.Error("Expected EnforceServiceExists to keep its default of true")
+			}
+			if mode := settings.EffectiveEnforcementMode(); mode != tt.expectedMode {
+				t.Errorf("Expected enforcement mode %s, got %s", tt.expectedMode, mode)
+			}
+			if strings.Contains(tt.raw, "validate_ingress_class") && !settings.ValidateIngressClass {
+				t.Error("Expected validate_ingress_class to be applied")
+			}
+		})
+	}
+}
+
+// 测试 validateSettings 函数：
+// 1) 空 payload 应 AcceptSettings
+// 2) JSON 格式错误应 RejectSettings。
+func TestValidateSettingsEntryPoint(t *testing.T) {
+	// 1. AcceptSettings on empty settings
+	acceptResp, err := ValidateSettings([]byte(``))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// validateSettings 返回的 JSON 中 code 为 200
+	// kubewarden.AcceptSettings() 底层是空 payload + HTTP 200
+	if string(acceptResp) == "" {
+		t.Errorf("Expected non-empty response for AcceptSettings()")
+	}
+
+	// 2. RejectSettings on invalid JSON
+	rejectResp, err := ValidateSettings([]byte(`{invalid json}`))
+	if err != nil {
+		// validateSettings 会返回 nil error and a RejectSettings payload
+		t.Fatalf("Expected validateSettings to handle error internally, got err=%v", err)
+	}
+	if string(rejectResp) == string(acceptResp) {
+		t.Errorf("Expected a different payload for RejectSettings() vs AcceptSettings()")
+	}
+}
+
+// 测试：格式错误的命名空间选择器应被 validateSettings 拒绝。
+func TestValidateSettingsRejectsMalformedSelector(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{"empty selector", `{"exempt_namespace_selector": {}}`},
+		{"unknown operator", `{"exempt_namespace_selector": {"matchExpressions": [{"key": "env", "operator": "Like", "values": ["dev"]}]}}`},
+		{"In without values", `{"exempt_namespace_selector": {"matchExpressions": [{"key": "env", "operator": "In"}]}}`},
+		{"Exists with values", `{"exempt_namespace_selector": {"matchExpressions": [{"key": "env", "operator": "Exists", "values": ["dev"]}]}}`},
+		{"missing key", `{"exempt_namespace_selector": {"matchExpressions": [{"operator": "Exists"}]}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := ValidateSettings([]byte(tt.payload))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var response kubewarden_protocol.SettingsValidationResponse
+			if err := json.Unmarshal(resp, &response); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if response.Valid {
+				t.Errorf("Expected settings %s to be rejected", tt.payload)
+			}
+		})
+	}
+
+	resp, _ := ValidateSettings([]byte(`{"exempt_namespace_selector": {"matchLabels": {"env": "dev"}}}`))
+	var response kubewarden_protocol.SettingsValidationResponse
+	if err := json.Unmarshal(resp, &response); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !response.Valid {
+		t.Errorf("Expected a valid selector to be accepted: %v", response.Message)
+	}
+}
+
+// 测试：enforcement_mode 优先于 enforce_service_exists，未设置时从后者推导。
+func TestEffectiveEnforcementMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		expected string
+		enabled  bool
+	}{
+		{"defaults", ``, enforcementModeDeny, true},
+		{"legacy disabled", `{"enforce_service_exists": false}`, enforcementModeOff, false},
+		{"warn mode", `{"enforcement_mode": "warn"}`, enforcementModeWarn, true},
+		{"off overrides legacy flag", `{"enforce_service_exists": true, "enforcement_mode": "off"}`, enforcementModeOff, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, err := NewSettingsFromValidationReq(makeValidationRequest([]byte(tt.payload)))
+			if err != nil {
+				t.Fatalf("Unexpected error creating settings: %v", err)
+			}
+			if mode := settings.EffectiveEnforcementMode(); mode != tt.expected {
+				t.Errorf("Expected mode %s, got %s", tt.expected, mode)
+			}
+			if settings.IsEnforcementEnabled() != tt.enabled {
+				t.Errorf("Expected IsEnforcementEnabled() to be %v", tt.enabled)
+			}
+		})
+	}
+}
+
+// 测试：未知的 enforcement_mode 应被拒绝。
+func TestInvalidEnforcementMode(t *testing.T) {
+	settings := Settings{EnforcementMode: "audit"}
+	if valid, err := settings.Valid(); valid || err == nil {
+		t.Errorf("Expected enforcement_mode 'audit' to be rejected")
+	}
+}
+
+// 测试：failure_policy 默认 fail，未知取值应被拒绝。
+func TestFailurePolicySettings(t *testing.T) {
+	settings := Settings{}
+	if policy := settings.EffectiveFailurePolicy(); policy != failurePolicyFail {
+		t.Errorf("Expected default failure_policy to be fail, got %s", policy)
+	}
+	settings.FailurePolicy = "retry"
+	if valid, err := settings.Valid(); valid || err == nil {
+		t.Errorf("Expected failure_policy 'retry' to be rejected")
+	}
+}
+
+// 测试：require_ready_endpoints 只接受 deny、warn、off。
+func TestRequireReadyEndpointsSettings(t *testing.T) {
+	settings := Settings{}
+	if settings.IsReadyEndpointsCheckEnabled() {
+		t.Error("Expected ready endpoints check to be disabled by default")
+	}
+	settings.RequireReadyEndpoints = enforcementModeWarn
+	if !settings.IsReadyEndpointsCheckEnabled() {
+		t.Error("Expected ready endpoints check to be enabled in warn mode")
+	}
+	settings.RequireReadyEndpoints = "strict"
+	if valid, err := settings.Valid(); valid || err == nil {
+		t.Errorf("Expected require_ready_endpoints 'strict' to be rejected")
+	}
+}
@@ -0,0 +1,271 @@
+package policy
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+)
+
+const (
+	// tlsSecretType 是 Ingress TLS 所需的 Secret 类型。
+	tlsSecretType = "kubernetes.io/tls"
+	tlsCertKey    = "tls.crt"
+	tlsKeyKey     = "tls.key"
+)
+
+// tlsSecretReference 描述 spec.tls 中对某个 Secret 的引用。
+type tlsSecretReference struct {
+	// Name 为引用的 Secret 名称。
+	Name string
+	// Hosts 为该 TLS 条目声明的主机名。
+	Hosts []string
+	// Location 描述引用出现的位置，例如 tls[0]。
+	Location string
+}
+
+// extractTLSSecretReferences 按出现顺序收集 spec.tls 中引用的 Secret。
+// 未设置 secretName 的条目交由控制器使用默认证书，不在此处校验。
+func extractTLSSecretReferences(ing *networkingv1.Ingress) []tlsSecretReference {
+	if ing == nil || ing.Spec == nil {
+		return nil
+	}
+	var refs []tlsSecretReference
+	for i, tls := range ing.Spec.TLS {
+		if tls == nil || tls.SecretName == "" {
+			continue
+		}
+		refs = append(refs, tlsSecretReference{
+			Name:     tls.SecretName,
+			Hosts:    tls.Hosts,
+			Location: fmt.Sprintf("tls[%d]", i),
+		})
+	}
+	return refs
+}
+
+// checkIngressTLS 校验 spec.tls 引用的 Secret，开启 verify_tls_certificates 时还校验 rules 中的主机都被证书覆盖。
+// oldIngress 不为 nil 时（validate_only_changed_backends），未变化的 TLS 条目与规则主机的问题写入 preExisting。
+func checkIngressTLS(ingress, oldIngress *networkingv1.Ingress, settings Settings, report, preExisting *validationReport) {
+	// 只有全部证书都成功解析时才检查 rules 中的主机是否被覆盖，避免重复报错
+	allVerified := true
+	var leafCerts []*x509.Certificate
+	checkChangedSeparately(extractTLSSecretReferences(ingress), extractTLSSecretReferences(oldIngress), tlsSecretIdentity,
+		report, preExisting, func(subset []tlsSecretReference, r *validationReport) {
+			certs, ok := checkTLSSecrets(ingress, subset, settings, r)
+			leafCerts = append(leafCerts, certs...)
+			allVerified = allVerified && ok
+		})
+
+	if !settings.VerifyTLSCertificates || !allVerified || len(leafCerts) == 0 {
+		return
+	}
+	checkChangedSeparately(extractRuleHostClaims(ingress), extractRuleHostClaims(oldIngress), hostClaimIdentity,
+		report, preExisting, func(subset []hostClaim, r *validationReport) {
+			for _, claim := range subset {
+				if !anyCertificateCoversHost(leafCerts, claim.Host) {
+					r.addViolation("host '%s' of %s is not covered by any TLS certificate of the Ingress", claim.Host, claim.Location)
+				}
+			}
+		})
+}
+
+// checkTLSSecrets 校验 refs 引用的 Secret 存在、类型为 kubernetes.io/tls
+// 且包含非空的 tls.crt 与 tls.key，问题写入 report。
+// 开启 verify_tls_certificates 时还会校验证书覆盖的主机名与有效期，并返回各 Secret 的叶子证书；
+// 有 Secret 或证书未通过校验时第二个返回值为 false。
+func checkTLSSecrets(
+	ingress *networkingv1.Ingress, refs []tlsSecretReference, settings Settings, report *validationReport,
+) ([]*x509.Certificate, bool) {
+	names := make([]string, 0, len(refs))
+	byName := make(map[string][]tlsSecretReference, len(refs))
+	for _, ref := range refs {
+		if _, ok := byName[ref.Name]; !ok {
+			names = append(names, ref.Name)
+		}
+		byName[ref.Name] = append(byName[ref.Name], ref)
+	}
+	// 按 Secret 名称排序，保证 host call 与报告的顺序稳定
+	sort.Strings(names)
+
+	namespace := ingress.Metadata.Namespace
+	allVerified := true
+	var leafCerts []*x509.Certificate
+	for _, name := range names {
+		secret, ok := checkTLSSecret(namespace, name, formatTLSLocations(byName[name]), settings, report)
+		if !ok {
+			allVerified = false
+			continue
+		}
+		if !settings.VerifyTLSCertificates {
+			continue
+		}
+		leaf, ok := checkTLSCertificate(ingress, settings, secret, byName[name], report)
+		if !ok {
+			allVerified = false
+			continue
+		}
+		leafCerts = append(leafCerts, leaf)
+	}
+	return leafCerts, allVerified
+}
+
+// extractRuleHostClaims 按出现顺序收集 spec.rules 中声明的主机名及其位置。
+func extractRuleHostClaims(ing *networkingv1.Ingress) []hostClaim {
+	if ing == nil || ing.Spec == nil {
+		return nil
+	}
+	var claims []hostClaim
+	for i, rule := range ing.Spec.Rules {
+		if rule != nil && rule.Host != "" {
+			claims = append(claims, hostClaim{Host: rule.Host, Location: fmt.Sprintf("rules[%d]", i)})
+		}
+	}
+	return claims
+}
+
+// checkTLSSecret 校验 TLS Secret 存在、类型为 kubernetes.io/tls 且包含非空的 tls.crt 与 tls.key，
+// 问题写入 report。Secret 合法时返回 Secret 与 true。
+func checkTLSSecret(namespace, name, locations string, settings Settings, report *validationReport) (*corev1.Secret, bool) {
+	secret, err := getSecret(namespace, name, settings)
+	if errors.Is(err, ErrResourceNotFound) {
+		report.addViolation("TLS Secret '%s' does not exist in namespace '%s' (referenced by %s)",
+			name, namespace, locations)
+		return nil, false
+	}
+	if err != nil {
+		report.addHostError(fmt.Sprintf("TLS Secret '%s'", name), locations, err)
+		return nil, false
+	}
+	if problem := tlsSecretProblem(secret); problem != "" {
+		report.addViolation("TLS Secret '%s' in namespace '%s' %s (referenced by %s)",
+			name, namespace, problem, locations)
+		return nil, false
+	}
+	return secret, true
+}
+
+// checkTLSCertificate 解析 Secret 中的证书，校验其 SAN 覆盖 TLS 条目中的主机、
+// 有效期满足 min_cert_validity_days，并在包含证书链时通过 host capability 校验信任链。
+// 证书可以解析时返回叶子证书。
+func checkTLSCertificate(
+	ingress *networkingv1.Ingress,
+	settings Settings,
+	secret *corev1.Secret,
+	refs []tlsSecretReference,
+	report *validationReport,
+) (*x509.Certificate, bool) {
+	name := refs[0].Name
+	namespace := ingress.Metadata.Namespace
+	locations := formatTLSLocations(refs)
+
+	certs, pemBlocks, err := parseCertificates(secret.Data[tlsCertKey])
+	if err != nil {
+		report.addViolation("TLS Secret '%s' in namespace '%s' has an invalid certificate: %s (referenced by %s)",
+			name, namespace, err, locations)
+		return nil, false
+	}
+	leaf := certs[0]
+
+	for _, ref := range refs {
+		hosts := ref.Hosts
+		if len(hosts) == 0 {
+			hosts = extractRuleHosts(ingress)
+		}
+		for _, h := range hosts {
+			if !certificateCoversHost(leaf, h) {
+				report.addViolation("TLS Secret '%s' in namespace '%s' holds a certificate that does not cover host '%s' "+
+					"(certificate SANs: %s) (referenced by %s)",
+					name, namespace, h, strings.Join(leaf.DNSNames, ", "), ref.Location)
+			}
+		}
+	}
+
+	now := time.Now()
+	if problem := certificateValidityProblem(leaf, now, settings.MinCertValidityDays); problem != "" {
+		report.addViolation("TLS Secret '%s' in namespace '%s': %s (referenced by %s)",
+			name, namespace, problem, locations)
+	}
+
+	// 只有 Secret 中附带了中间证书时才需要校验信任链
+	if len(pemBlocks) > 1 {
+		notAfter := now.Add(time.Duration(settings.MinCertValidityDays) * hoursPerDay * time.Hour)
+		resp, err := verifyCertificateChain(pemBlocks[0], pemBlocks[1:], notAfter)
+		switch {
+		case err != nil:
+			report.addHostError(fmt.Sprintf("TLS Secret '%s' certificate chain", name), locations, err)
+		case !resp.Trusted:
+			report.addViolation("TLS Secret '%s' in namespace '%s' holds a certificate that is not trusted by its chain: %s "+
+				"(referenced by %s)", name, namespace, resp.Reason, locations)
+		}
+	}
+	return leaf, true
+}
+
+// anyCertificateCoversHost 判断是否有任意证书覆盖给定主机名。
+func anyCertificateCoversHost(certs []*x509.Certificate, host string) bool {
+	for _, cert := range certs {
+		if certificateCoversHost(cert, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// tlsSecretProblem 返回 Secret 不满足 TLS 要求的原因；满足时返回空字符串。
+func tlsSecretProblem(secret *corev1.Secret) string {
+	if secret.Type != tlsSecretType {
+		secretType := secret.Type
+		if secretType == "" {
+			secretType = "Opaque"
+		}
+		return fmt.Sprintf("has type '%s' instead of '%s'", secretType, tlsSecretType)
+	}
+	var missing []string
+	for _, key := range []string{tlsCertKey, tlsKeyKey} {
+		if len(secret.Data[key]) == 0 {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Sprintf("has empty or missing %s", strings.Join(missing, ", "))
+	}
+	return ""
+}
+
+// getSecret 通过 host capabilities 获取 Secret；不存在时返回 ErrResourceNotFound。
+func getSecret(namespace, name string, settings Settings) (*corev1.Secret, error) {
+	respBytes, err := getResource(getResourceRequest{
+		APIVersion:   "v1",
+		Kind:         "Secret",
+		Namespace:    namespace,
+		Name:         name,
+		DisableCache: settings.DisableCache,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(respBytes) == 0 {
+		return nil, ErrResourceNotFound
+	}
+	secret := &corev1.Secret{}
+	if err := json.Unmarshal(respBytes, secret); err != nil {
+		return nil, fmt.Errorf("cannot decode Secret '%s': %w", name, err)
+	}
+	return secret, nil
+}
+
+// formatTLSLocations 将多个 TLS 引用位置拼接为可读字符串。
+func formatTLSLocations(refs []tlsSecretReference) string {
+	locations := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		locations = append(locations, ref.Location)
+	}
+	return strings.Join(locations, ", ")
+}
@@ -0,0 +1,70 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+)
+
+// tlsIngress 构造一个引用给定 TLS Secret 的 Ingress，后端 Service 均存在。
+func tlsIngress(secretNames ...string) networkingv1.Ingress {
+	ingress := networkingv1.Ingress{
+		Metadata: &metav1.ObjectMeta{
+			Name:      "tls-ingress",
+			Namespace: "default",
+		},
+		Spec: &networkingv1.IngressSpec{
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: strPtr("my-service"),
+					Port: &networkingv1.ServiceBackendPort{Number: 80},
+				},
+			},
+		},
+	}
+	for _, name := range secretNames {
+		ingress.Spec.TLS = append(ingress.Spec.TLS, &networkingv1.IngressTLS{
+			Hosts:      []string{"foo.example.com"},
+			SecretName: name,
+		})
+	}
+	return ingress
+}
+
+func TestTLSSecretCheckDisabledByDefault(t *testing.T) {
+	ingress := tlsIngress("missing-secret")
+	settings := Settings{EnforceServiceExists: true}
+	if response := runValidation(t, &ingress, &settings); !response.Accepted {
+		t.Errorf("Expected TLS Secrets to be ignored by default, got: %s", *response.Message)
+	}
+}
+
+func TestValidTLSSecretIsAccepted(t *testing.T) {
+	ingress := tlsIngress("tls-secret")
+	settings := Settings{EnforceServiceExists: true, EnforceTLSSecretExists: true}
+	if response := runValidation(t, &ingress, &settings); !response.Accepted {
+		t.Errorf("Unexpected rejection: %s", *response.Message)
+	}
+}
+
+func TestInvalidTLSSecretsAreRejected(t *testing.T) {
+	ingress := tlsIngress("missing-secret", "opaque-secret", "empty-tls-secret")
+	settings := Settings{EnforceServiceExists: true, EnforceTLSSecretExists: true}
+	response := runValidation(t, &ingress, &settings)
+	if response.Accepted {
+		t.Fatal("Expected rejection for invalid TLS Secrets")
+	}
+
+	expected := []string{
+		"TLS Secret 'missing-secret' does not exist in namespace 'default' (referenced by tls[0])",
+		"TLS Secret 'opaque-secret' in namespace 'default' has type 'Opaque' instead of 'kubernetes.io/tls' (referenced by tls[1])",
+		"TLS Secret 'empty-tls-secret' in namespace 'default' has empty or missing tls.key (referenced by tls[2])",
+	}
+	for _, msg := range expected {
+		if !strings.Contains(*response.Message, msg) {
+			t.Errorf("Expected message to contain '%s', got '%s'", msg, *response.Message)
+		}
+	}
+}
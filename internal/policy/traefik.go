@@ -0,0 +1,421 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	onelog "github.com/francoispqt/onelog"
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+	kubewarden "github.com/kubewarden/policy-sdk-go"
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+const (
+	traefikAPIGroup       = "traefik.io"
+	traefikAPIVersion     = traefikAPIGroup + "/v1alpha1"
+	ingressRouteKind      = "IngressRoute"
+	traefikServiceKind    = "TraefikService"
+	traefikMiddlewareKind = "Middleware"
+	// traefikCRDProviderSuffix 是 Traefik 中 Kubernetes CRD provider 对象的名称后缀。
+	traefikCRDProviderSuffix = "@kubernetescrd"
+	// maxTraefikServiceDepth 限制 TraefikService 嵌套解析的深度，防止病态配置放大 host call。
+	maxTraefikServiceDepth = 8
+)
+
+// annotationControllerTraefik 是 Traefik 注解提取器的名称。
+const annotationControllerTraefik = "traefik"
+
+// traefikRouterMiddlewaresAnnotation 的值为逗号分隔的 Middleware 列表，例如 default-auth@kubernetescrd。
+const traefikRouterMiddlewaresAnnotation = "traefik.ingress.kubernetes.io/router.middlewares"
+
+// ingressRoute 是 traefik.io/v1alpha1 IngressRoute 中校验需要的字段子集，
+// k8s-objects 没有提供 Traefik 类型。
+type ingressRoute struct {
+	APIVersion string             `json:"apiVersion,omitempty"`
+	Kind       string             `json:"kind,omitempty"`
+	Metadata   *metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec       *ingressRouteSpec  `json:"spec,omitempty"`
+}
+
+type ingressRouteSpec struct {
+	Routes []*ingressRouteRoute `json:"routes,omitempty"`
+	TLS    *ingressRouteTLS     `json:"tls,omitempty"`
+}
+
+type ingressRouteRoute struct {
+	Services    []*traefikServiceRef    `json:"services,omitempty"`
+	Middlewares []*traefikMiddlewareRef `json:"middlewares,omitempty"`
+}
+
+type ingressRouteTLS struct {
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// traefikServiceRef 对应 IngressRoute 与 TraefikService 中的服务引用，kind 为 Service（默认）或 TraefikService。
+type traefikServiceRef struct {
+	Name      string           `json:"name"`
+	Namespace string           `json:"namespace,omitempty"`
+	Kind      string           `json:"kind,omitempty"`
+	Port      *intOrStringPort `json:"port,omitempty"`
+}
+
+type traefikMiddlewareRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// traefikService 是 TraefikService 中校验需要的字段子集。
+type traefikService struct {
+	Metadata *metav1.ObjectMeta  `json:"metadata,omitempty"`
+	Spec     *traefikServiceSpec `json:"spec,omitempty"`
+}
+
+type traefikServiceSpec struct {
+	Weighted  *traefikWeighted  `json:"weighted,omitempty"`
+	Mirroring *traefikMirroring `json:"mirroring,omitempty"`
+}
+
+type traefikWeighted struct {
+	Services []*traefikServiceRef `json:"services,omitempty"`
+}
+
+type traefikMirroring struct {
+	traefikServiceRef
+	Mirrors []*traefikServiceRef `json:"mirrors,omitempty"`
+}
+
+// validateIngressRoute 校验 Traefik IngressRoute 引用的 Service、TraefikService、Middleware 与 TLS Secret。
+func validateIngressRoute(validationRequest *kubewarden_protocol.ValidationRequest, settings Settings) ([]byte, error) {
+	route, err := getIngressRoute(validationRequest.Request.Object)
+	if err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(fmt.Sprintf("Cannot decode %s: %s", ingressRouteKind, err)),
+			kubewarden.Code(httpBadRequestStatusCode))
+	}
+
+	logger.DebugWithFields("validating ingress route", func(e onelog.Entry) {
+		e.String("name", route.Metadata.Name)
+		e.String("namespace", route.Metadata.Namespace)
+	})
+
+	if !settings.IsEnforcementEnabled() {
+		return kubewarden.AcceptRequest()
+	}
+	if exempt, response, err := handleExemptions(route.Metadata, settings); exempt {
+		return response, err
+	}
+
+	report := newValidationReport()
+	preExisting := newValidationReport()
+	oldRoute := decodeOldObject(settings, validationRequest, getIngressRoute)
+	services, middlewares := extractIngressRouteReferences(route)
+	oldServices, oldMiddlewares := extractIngressRouteReferences(oldRoute)
+	lookup := newServiceLookup(settings)
+	checkChangedSeparately(services, oldServices, ingressRouteServiceIdentity, report, preExisting,
+		func(subset []ingressRouteServiceReference, r *validationReport) {
+			resolver := newTraefikServiceResolver(settings, r)
+			for _, ref := range subset {
+				resolver.resolve(ref.Namespace, ref.Ref, ref.Location, nil)
+			}
+			checkServiceReferences(settings, lookup, resolver.refs, r)
+		})
+	checkChangedSeparately(middlewares, oldMiddlewares, objectReferenceIdentity, report, preExisting,
+		func(subset []objectReference, r *validationReport) {
+			var valid []objectReference
+			for _, ref := range subset {
+				if len(ref.Candidates) == 0 {
+					r.addViolation("%s is invalid: '%s' is not of the form namespace-name%s",
+						ref.Location, ref.Display, traefikCRDProviderSuffix)
+					continue
+				}
+				valid = append(valid, ref)
+			}
+			checkObjectReferences(settings, valid, r)
+		})
+	// 与 Ingress 的 spec.tls 相同，只在开启 enforce_tls_secret_exists 时校验 tls.secretName
+	if settings.EnforceTLSSecretExists {
+		checkChangedSeparately(ingressRouteTLSSecretNames(route), ingressRouteTLSSecretNames(oldRoute), stringIdentity,
+			report, preExisting, func(subset []string, r *validationReport) {
+				for _, name := range subset {
+					checkTLSSecret(route.Metadata.Namespace, name, "tls.secretName", settings, r)
+				}
+			})
+	}
+	report.addPreExistingReport(preExisting)
+	return respondWithReport(settings, report, validationRequest.Request.Uid, route.Metadata)
+}
+
+// ingressRouteServiceReference 是 routes[].services[] 中的一个服务引用，Namespace 为其所在对象的命名空间。
+type ingressRouteServiceReference struct {
+	Namespace string
+	Ref       *traefikServiceRef
+	Location  string
+}
+
+// ingressRouteServiceIdentity 返回 IngressRoute 服务引用的比较标识：命名空间、类型、名称与端口。
+func ingressRouteServiceIdentity(ref ingressRouteServiceReference) string {
+	namespace := ref.Namespace
+	if ref.Ref.Namespace != "" {
+		namespace = ref.Ref.Namespace
+	}
+	port := ""
+	if ref.Ref.Port != nil {
+		port = backendPortIdentity(&ref.Ref.Port.ServiceBackendPort)
+	}
+	return fmt.Sprintf("%s/%s/%s:%s", namespace, ref.Ref.Kind, ref.Ref.Name, port)
+}
+
+// extractIngressRouteReferences 按出现顺序收集 IngressRoute 中的服务引用与 Middleware 引用。
+// 无法解析为 namespace-name 的 @kubernetescrd Middleware 没有候选对象，由调用方报告。
+func extractIngressRouteReferences(route *ingressRoute) ([]ingressRouteServiceReference, []objectReference) {
+	if route == nil || route.Spec == nil {
+		return nil, nil
+	}
+	namespace := route.Metadata.Namespace
+	var services []ingressRouteServiceReference
+	var middlewares []objectReference
+	for i, r := range route.Spec.Routes {
+		if r == nil {
+			continue
+		}
+		for j, svc := range r.Services {
+			if svc != nil {
+				services = append(services, ingressRouteServiceReference{
+					Namespace: namespace,
+					Ref:       svc,
+					Location:  fmt.Sprintf("routes[%d].services[%d]", i, j),
+				})
+			}
+		}
+		for j, mw := range r.Middlewares {
+			if ref, ok := ingressRouteMiddlewareReference(namespace, mw, fmt.Sprintf("routes[%d].middlewares[%d]", i, j)); ok {
+				middlewares = append(middlewares, ref)
+			}
+		}
+	}
+	return services, middlewares
+}
+
+// ingressRouteTLSSecretNames 返回 tls.secretName，未设置时返回 nil。
+func ingressRouteTLSSecretNames(route *ingressRoute) []string {
+	if route == nil || route.Spec == nil || route.Spec.TLS == nil || route.Spec.TLS.SecretName == "" {
+		return nil
+	}
+	return []string{route.Spec.TLS.SecretName}
+}
+
+// getIngressRoute 从 RAW JSON 中解析出 IngressRoute 对象。
+func getIngressRoute(rawJSON json.RawMessage) (*ingressRoute, error) {
+	if len(rawJSON) == 0 {
+		return nil, errors.New("empty IngressRoute object")
+	}
+	route := &ingressRoute{}
+	if err := json.Unmarshal(rawJSON, route); err != nil {
+		return nil, err
+	}
+	if route.Metadata == nil {
+		return nil, errors.New("IngressRoute metadata is missing")
+	}
+	return route, nil
+}
+
+// traefikServiceResolver 把 IngressRoute 的服务引用展开为 Service 引用，
+// 沿 TraefikService 的 weighted 与 mirroring 递归解析，同一请求内每个 TraefikService 只获取一次。
+type traefikServiceResolver struct {
+	settings Settings
+	report   *validationReport
+	cache    map[string]*traefikServiceLookupResult
+	// refs 为展开得到的 Service 引用，交给 checkServiceReferences 校验。
+	refs []serviceReference
+}
+
+type traefikServiceLookupResult struct {
+	service *traefikService
+	err     error
+}
+
+func newTraefikServiceResolver(settings Settings, report *validationReport) *traefikServiceResolver {
+	return &traefikServiceResolver{
+		settings: settings,
+		report:   report,
+		cache:    map[string]*traefikServiceLookupResult{},
+	}
+}
+
+// resolve 解析一个服务引用。namespace 为引用所在对象的命名空间，visiting 为当前解析路径上的 TraefikService。
+// 名称带有 provider 后缀（例如 api@internal）的引用指向集群外的对象，不做校验。
+func (r *traefikServiceResolver) resolve(namespace string, ref *traefikServiceRef, location string, visiting []string) {
+	if ref == nil || ref.Name == "" || strings.Contains(ref.Name, "@") {
+		return
+	}
+	if ref.Namespace != "" {
+		namespace = ref.Namespace
+	}
+	switch ref.Kind {
+	case "", serviceKind:
+		serviceRef := serviceReference{Namespace: namespace, Name: ref.Name, Location: location}
+		if ref.Port != nil {
+			port := ref.Port.ServiceBackendPort
+			serviceRef.Port = &port
+		}
+		r.refs = append(r.refs, serviceRef)
+	case traefikServiceKind:
+		r.resolveTraefikService(namespace, ref.Name, location, visiting)
+	default:
+		r.report.addViolation("%s has unsupported kind '%s': must be %s or %s",
+			location, ref.Kind, serviceKind, traefikServiceKind)
+	}
+}
+
+// resolveTraefikService 获取 TraefikService 并继续解析其中的服务引用。
+func (r *traefikServiceResolver) resolveTraefikService(namespace, name, location string, visiting []string) {
+	key := namespace + "/" + name
+	for _, seen := range visiting {
+		if seen == key {
+			r.report.addViolation("TraefikService '%s' in namespace '%s' references itself (referenced by %s)",
+				name, namespace, location)
+			return
+		}
+	}
+	if len(visiting) >= maxTraefikServiceDepth {
+		r.report.addViolation("TraefikService '%s' in namespace '%s' is nested more than %d levels deep (referenced by %s)",
+			name, namespace, maxTraefikServiceDepth, location)
+		return
+	}
+
+	result, ok := r.cache[key]
+	if !ok {
+		service, err := getTraefikService(namespace, name, r.settings)
+		result = &traefikServiceLookupResult{service: service, err: err}
+		r.cache[key] = result
+	}
+	if errors.Is(result.err, ErrResourceNotFound) {
+		r.report.addViolation("TraefikService '%s' does not exist in namespace '%s' (referenced by %s)",
+			name, namespace, location)
+		return
+	}
+	if result.err != nil {
+		r.report.addHostError(fmt.Sprintf("TraefikService '%s'", name), location, result.err)
+		return
+	}
+	if result.service.Spec == nil {
+		return
+	}
+
+	visiting = append(visiting, key)
+	prefix := fmt.Sprintf("%s -> TraefikService '%s'", location, name)
+	if weighted := result.service.Spec.Weighted; weighted != nil {
+		for i, svc := range weighted.Services {
+			r.resolve(namespace, svc, fmt.Sprintf("%s weighted.services[%d]", prefix, i), visiting)
+		}
+	}
+	if mirroring := result.service.Spec.Mirroring; mirroring != nil {
+		r.resolve(namespace, &mirroring.traefikServiceRef, prefix+" mirroring", visiting)
+		for i, svc := range mirroring.Mirrors {
+			r.resolve(namespace, svc, fmt.Sprintf("%s mirroring.mirrors[%d]", prefix, i), visiting)
+		}
+	}
+}
+
+// getTraefikService 通过 host capabilities 获取 TraefikService；不存在时返回 ErrResourceNotFound。
+func getTraefikService(namespace, name string, settings Settings) (*traefikService, error) {
+	respBytes, err := getResource(getResourceRequest{
+		APIVersion:   traefikAPIVersion,
+		Kind:         traefikServiceKind,
+		Namespace:    namespace,
+		Name:         name,
+		DisableCache: settings.DisableCache,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(respBytes) == 0 {
+		return nil, ErrResourceNotFound
+	}
+	service := &traefikService{}
+	if err := json.Unmarshal(respBytes, service); err != nil {
+		return nil, fmt.Errorf("cannot decode TraefikService '%s': %w", name, err)
+	}
+	return service, nil
+}
+
+// ingressRouteMiddlewareReference 返回 IngressRoute 中 Middleware 引用对应的对象引用。
+// 带有 @kubernetescrd 后缀的名称按 Traefik 的 namespace-name 规则解析，其他 provider 的 Middleware 不做校验。
+func ingressRouteMiddlewareReference(namespace string, mw *traefikMiddlewareRef, location string) (objectReference, bool) {
+	if mw == nil || mw.Name == "" {
+		return objectReference{}, false
+	}
+	if strings.Contains(mw.Name, "@") {
+		return traefikCRDMiddlewareReference(mw.Name, namespace, location)
+	}
+	if mw.Namespace != "" {
+		namespace = mw.Namespace
+	}
+	return objectReference{
+		APIVersion: traefikAPIVersion,
+		Kind:       traefikMiddlewareKind,
+		Display:    mw.Name,
+		Candidates: []namespacedName{{Namespace: namespace, Name: mw.Name}},
+		Location:   location,
+	}, true
+}
+
+// traefikCRDMiddlewareReference 解析 namespace-name@kubernetescrd 形式的 Middleware 名称。
+// 命名空间与名称都可能包含 '-'，因此每个 '-' 都是一种拆分方式，以 preferredNamespace 开头的拆分优先。
+// 不以 @kubernetescrd 结尾的名称属于其他 provider，第二个返回值为 false。
+func traefikCRDMiddlewareReference(value, preferredNamespace, location string) (objectReference, bool) {
+	if !strings.HasSuffix(value, traefikCRDProviderSuffix) {
+		return objectReference{}, false
+	}
+	qualified := strings.TrimSuffix(value, traefikCRDProviderSuffix)
+	var preferred, others []namespacedName
+	for i := 1; i < len(qualified)-1; i++ {
+		if qualified[i] != '-' {
+			continue
+		}
+		candidate := namespacedName{Namespace: qualified[:i], Name: qualified[i+1:]}
+		if candidate.Namespace == preferredNamespace {
+			preferred = append(preferred, candidate)
+		} else {
+			others = append(others, candidate)
+		}
+	}
+	return objectReference{
+		APIVersion: traefikAPIVersion,
+		Kind:       traefikMiddlewareKind,
+		Display:    value,
+		Candidates: append(preferred, others...),
+		Location:   location,
+	}, true
+}
+
+// extractTraefikAnnotationReferences 把 router.middlewares 注解中的 @kubernetescrd Middleware 解析为对象引用。
+func extractTraefikAnnotationReferences(ing *networkingv1.Ingress) (annotationReferences, []string) {
+	var refs annotationReferences
+	value, ok := ing.Metadata.Annotations[traefikRouterMiddlewaresAnnotation]
+	if !ok {
+		return refs, nil
+	}
+	var problems []string
+	location := formatAnnotationLocation(traefikRouterMiddlewaresAnnotation)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		ref, ok := traefikCRDMiddlewareReference(entry, ing.Metadata.Namespace, location)
+		if !ok {
+			continue
+		}
+		if len(ref.Candidates) == 0 {
+			problems = append(problems, fmt.Sprintf("%s is invalid: '%s' is not of the form namespace-name%s",
+				location, entry, traefikCRDProviderSuffix))
+			continue
+		}
+		refs.Objects = append(refs.Objects, ref)
+	}
+	return refs, problems
+}
@@ -0,0 +1,211 @@
+package policy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+)
+
+// traefikFixtures 返回 Traefik 测试使用的 TraefikService 与 Middleware。
+func traefikFixtures() mockResources {
+	meta := func(namespace, name string) *metav1.ObjectMeta {
+		return &metav1.ObjectMeta{Name: name, Namespace: namespace}
+	}
+	spec := func(value map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"spec": value}
+	}
+	weighted := func(services ...map[string]interface{}) map[string]interface{} {
+		return spec(map[string]interface{}{"weighted": map[string]interface{}{"services": services}})
+	}
+	return mockResources{}.
+		addObject(traefikAPIVersion, traefikServiceKind, meta("default", "canary"), weighted(
+			map[string]interface{}{"name": "my-service", "port": 80, "weight": 90},
+			map[string]interface{}{"name": "shadow", "kind": traefikServiceKind, "weight": 10})).
+		addObject(traefikAPIVersion, traefikServiceKind, meta("default", "shadow"), spec(map[string]interface{}{
+			"mirroring": map[string]interface{}{
+				"name": "my-service",
+				"port": "web",
+				"mirrors": []map[string]interface{}{
+					{"name": "shared-service", "namespace": "shared", "port": 80, "percent": 10},
+				},
+			},
+		})).
+		addObject(traefikAPIVersion, traefikServiceKind, meta("default", "broken"), weighted(
+			map[string]interface{}{"name": "gone-service", "port": 80})).
+		addObject(traefikAPIVersion, traefikServiceKind, meta("default", "loop"), weighted(
+			map[string]interface{}{"name": "loop", "kind": traefikServiceKind})).
+		addObject(traefikAPIVersion, traefikMiddlewareKind, meta("default", "auth"), spec(map[string]interface{}{
+			"basicAuth": map[string]interface{}{"secret": "auth-users"},
+		})).
+		addObject(traefikAPIVersion, traefikMiddlewareKind, meta("team-a", "strip-prefix"), spec(map[string]interface{}{
+			"stripPrefix": map[string]interface{}{"prefixes": []string{"/api"}},
+		}))
+}
+
+// ingressRouteJSON 构造只有一条路由的 IngressRoute。
+func ingressRouteJSON(services, middlewares, tls string) json.RawMessage {
+	spec := `{"routes":[{"match":"Host(` + "`example.com`" + `)","kind":"Rule","services":` + services
+	if middlewares != "" {
+		spec += `,"middlewares":` + middlewares
+	}
+	spec += `}]`
+	if tls != "" {
+		spec += `,"tls":` + tls
+	}
+	spec += `}`
+	return json.RawMessage(`{"apiVersion":"traefik.io/v1alpha1","kind":"IngressRoute",` +
+		`"metadata":{"name":"test-route","namespace":"default"},"spec":` + spec + `}`)
+}
+
+func TestIngressRoute(t *testing.T) {
+	tests := []struct {
+		name          string
+		route         json.RawMessage
+		expectMessage string
+	}{
+		{
+			name: "services, TraefikService chain, middlewares and TLS secret exist",
+			route: ingressRouteJSON(`[{"name":"my-service","port":"http"},{"name":"canary","kind":"TraefikService"},`+
+				`{"name":"api@internal","kind":"TraefikService"}]`,
+				`[{"name":"auth"},{"name":"strip-prefix","namespace":"team-a"},{"name":"compress@file"}]`,
+				`{"secretName":"tls-secret"}`),
+		},
+		{
+			name:          "missing service",
+			route:         ingressRouteJSON(`[{"name":"missing-service","port":80}]`, "", ""),
+			expectMessage: "Service 'missing-service' does not exist in namespace 'default' (referenced by routes[0].services[0])",
+		},
+		{
+			name:          "port not exposed",
+			route:         ingressRouteJSON(`[{"name":"my-service","port":8443}]`, "", ""),
+			expectMessage: "Service 'my-service' in namespace 'default' does not expose port 8443 referenced by routes[0].services[0]",
+		},
+		{
+			name:          "missing TraefikService",
+			route:         ingressRouteJSON(`[{"name":"blue-green","kind":"TraefikService"}]`, "", ""),
+			expectMessage: "TraefikService 'blue-green' does not exist in namespace 'default' (referenced by routes[0].services[0])",
+		},
+		{
+			name:  "missing service behind a TraefikService",
+			route: ingressRouteJSON(`[{"name":"broken","kind":"TraefikService"}]`, "", ""),
+			expectMessage: "Service 'gone-service' does not exist in namespace 'default' " +
+				"(referenced by routes[0].services[0] -> TraefikService 'broken' weighted.services[0])",
+		},
+		{
+			name:          "TraefikService cycle",
+			route:         ingressRouteJSON(`[{"name":"loop","kind":"TraefikService"}]`, "", ""),
+			expectMessage: "TraefikService 'loop' in namespace 'default' references itself",
+		},
+		{
+			name:          "unsupported service kind",
+			route:         ingressRouteJSON(`[{"name":"my-service","kind":"Deployment"}]`, "", ""),
+			expectMessage: "routes[0].services[0] has unsupported kind 'Deployment'",
+		},
+		{
+			name:          "missing middleware",
+			route:         ingressRouteJSON(`[{"name":"my-service","port":80}]`, `[{"name":"rate-limit"}]`, ""),
+			expectMessage: "Middleware 'rate-limit' does not exist in namespace 'default' (referenced by routes[0].middlewares[0])",
+		},
+		{
+			name:          "missing TLS secret",
+			route:         ingressRouteJSON(`[{"name":"my-service","port":80}]`, "", `{"secretName":"missing-tls"}`),
+			expectMessage: "TLS Secret 'missing-tls' does not exist in namespace 'default' (referenced by tls.secretName)",
+		},
+		{
+			name:          "TLS secret of the wrong type",
+			route:         ingressRouteJSON(`[{"name":"my-service","port":80}]`, "", `{"secretName":"opaque-secret"}`),
+			expectMessage: "TLS Secret 'opaque-secret' in namespace 'default' has type 'Opaque' instead of 'kubernetes.io/tls' (referenced by tls.secretName)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host.Client = newMockWapcClient(traefikFixtures())
+			settings := Settings{EnforceServiceExists: true, EnforceTLSSecretExists: true}
+			response := validateKindWithClient(t, traefikAPIGroup, ingressRouteKind, tt.route, &settings)
+			if tt.expectMessage == "" {
+				if !response.Accepted {
+					t.Errorf("Unexpected rejection: %s", *response.Message)
+				}
+				return
+			}
+			if response.Accepted {
+				t.Fatal("Expected rejection for a broken IngressRoute reference")
+			}
+			if !strings.Contains(*response.Message, tt.expectMessage) {
+				t.Errorf("Expected message to contain '%s', got '%s'", tt.expectMessage, *response.Message)
+			}
+		})
+	}
+}
+
+func TestIngressRouteTLSSecretRequiresSetting(t *testing.T) {
+	host.Client = newMockWapcClient(traefikFixtures())
+	settings := Settings{EnforceServiceExists: true}
+	route := ingressRouteJSON(`[{"name":"my-service","port":80}]`, "", `{"secretName":"missing-tls"}`)
+	if response := validateKindWithClient(t, traefikAPIGroup, ingressRouteKind, route, &settings); !response.Accepted {
+		t.Errorf("Expected tls.secretName to be unchecked without enforce_tls_secret_exists, got: %s", *response.Message)
+	}
+}
+
+func TestTraefikRouterMiddlewaresAnnotation(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		expectMessage string
+	}{
+		{
+			name:  "middlewares in the Ingress namespace and another namespace",
+			value: "default-auth@kubernetescrd, team-a-strip-prefix@kubernetescrd, compress@file",
+		},
+		{
+			name:  "missing middleware lists every namespace split",
+			value: "team-a-rate-limit@kubernetescrd",
+			expectMessage: "Middleware 'team-a-rate-limit@kubernetescrd' does not match any existing Middleware " +
+				"(tried team/a-rate-limit, team-a/rate-limit, team-a-rate/limit) " +
+				"(referenced by annotation 'traefik.ingress.kubernetes.io/router.middlewares')",
+		},
+		{
+			name:          "name without a namespace",
+			value:         "auth@kubernetescrd",
+			expectMessage: "annotation 'traefik.ingress.kubernetes.io/router.middlewares' is invalid: 'auth@kubernetescrd' is not of the form namespace-name@kubernetescrd",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host.Client = newMockWapcClient(traefikFixtures())
+			ingress := pathIngress("my-service")
+			ingress.Metadata.Annotations = map[string]string{traefikRouterMiddlewaresAnnotation: tt.value}
+			settings := Settings{
+				EnforceServiceExists:           true,
+				AnnotationReferenceControllers: []string{annotationControllerTraefik},
+			}
+			response := validateWithClient(t, ingress, &settings)
+			if tt.expectMessage == "" {
+				if !response.Accepted {
+					t.Errorf("Unexpected rejection: %s", *response.Message)
+				}
+				return
+			}
+			if response.Accepted {
+				t.Fatal("Expected rejection for a broken middleware reference")
+			}
+			if !strings.Contains(*response.Message, tt.expectMessage) {
+				t.Errorf("Expected message to contain '%s', got '%s'", tt.expectMessage, *response.Message)
+			}
+		})
+	}
+}
+
+func TestTraefikCRDMiddlewarePrefersIngressNamespace(t *testing.T) {
+	ref, ok := traefikCRDMiddlewareReference("team-a-auth@kubernetescrd", "team-a", "annotation")
+	if !ok {
+		t.Fatal("Expected a kubernetescrd middleware to be resolved")
+	}
+	if first := ref.Candidates[0]; first.Namespace != "team-a" || first.Name != "auth" {
+		t.Errorf("Expected the Ingress namespace split first, got %+v", ref.Candidates)
+	}
+}
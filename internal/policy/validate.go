@@ -0,0 +1,565 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	onelog "github.com/francoispqt/onelog"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+	"github.com/kubewarden/k8s-objects/apimachinery/pkg/util/intstr"
+	kubewarden "github.com/kubewarden/policy-sdk-go"
+	"github.com/kubewarden/policy-sdk-go/pkg/capabilities"
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+)
+
+const httpBadRequestStatusCode = 400
+
+const (
+	ingressAPIGroup = "networking.k8s.io"
+	ingressKind     = "Ingress"
+)
+
+// AdmissionRequest 中的操作类型。
+const (
+	operationUpdate = "UPDATE"
+	operationDelete = "DELETE"
+)
+
+//nolint:gochecknoglobals // host 是 Kubewarden SDK 推荐的全局变量使用方式
+var host = capabilities.NewHost()
+
+// SetHostClient 替换 host capabilities 的调用方，供 wasm 运行时之外的调用方
+// （例如离线 CLI）提供资源。
+func SetHostClient(client capabilities.WapcClient) {
+	host.Client = client
+}
+
+// Validate 是策略的 validate 入口，校验 AdmissionRequest 并返回 ValidationResponse。
+func Validate(payload []byte) ([]byte, error) {
+	// 从传入的 payload 创建 ValidationRequest 实例
+	validationRequest := kubewarden_protocol.ValidationRequest{}
+	err := json.Unmarshal(payload, &validationRequest)
+	if err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
+			kubewarden.Code(httpBadRequestStatusCode))
+	}
+
+	// 从 ValidationRequest 对象创建 Settings 实例
+	settings, err := NewSettingsFromValidationReq(&validationRequest)
+	if err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(err.Error()),
+			kubewarden.Code(httpBadRequestStatusCode))
+	}
+
+	// 根据请求资源的 API 组与类型选择对应的校验逻辑
+	kind := validationRequest.Request.Kind
+	if kind.Kind == "" {
+		// 向后兼容：未设置 Kind 的请求按 Ingress 处理
+		return validateIngress(&validationRequest, settings)
+	}
+	validate, ok := kindValidators[groupKind(kind.Group, kind.Kind)]
+	if !ok {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(fmt.Sprintf("Resource kind '%s' is not supported by this policy", groupKind(kind.Group, kind.Kind))),
+			kubewarden.Code(httpBadRequestStatusCode))
+	}
+	return validate(&validationRequest, settings)
+}
+
+// kindValidator 解码并校验某一种资源类型的 AdmissionRequest。
+type kindValidator func(validationRequest *kubewarden_protocol.ValidationRequest, settings Settings) ([]byte, error)
+
+// kindValidators 按 API 组与类型（见 groupKind）选择校验逻辑。Kind 在不同 API 组之间并不唯一
+// （例如 Route、IngressRoute），因此键中必须包含组。支持新的资源类型时在此注册，
+// 同时需要在 metadata.yml 的 rules 中加入对应资源。
+//
+//nolint:gochecknoglobals // 只读的查找表
+var kindValidators = map[string]kindValidator{
+	groupKind(ingressAPIGroup, ingressKind):                validateIngress,
+	groupKind(gatewayAPIGroup, httpRouteKind):              validateGatewayRoute,
+	groupKind(gatewayAPIGroup, grpcRouteKind):              validateGatewayRoute,
+	groupKind("", serviceKind):                             validateServiceDeletion,
+	groupKind(traefikAPIGroup, ingressRouteKind):           validateIngressRoute,
+	groupKind(istioNetworkingAPIGroup, virtualServiceKind): validateVirtualService,
+	groupKind(openShiftRouteAPIGroup, openShiftRouteKind):  validateOpenShiftRoute,
+}
+
+// groupKind 返回 kindValidators 使用的键，例如 route.openshift.io/Route；核心组的资源只有类型，例如 Service。
+func groupKind(group, kind string) string {
+	if group == "" {
+		return kind
+	}
+	return group + "/" + kind
+}
+
+// validateIngress 校验 networking.k8s.io/v1 Ingress。
+func validateIngress(validationRequest *kubewarden_protocol.ValidationRequest, settings Settings) ([]byte, error) {
+	// 反序列化出 Ingress 对象
+	ingress, err := getIngress(validationRequest.Request.Object)
+	if err != nil {
+		return kubewarden.RejectRequest(
+			kubewarden.Message(fmt.Sprintf("Cannot decode Ingress: %s", err)),
+			kubewarden.Code(httpBadRequestStatusCode))
+	}
+
+	logger.DebugWithFields("validating ingress object", func(e onelog.Entry) {
+		e.String("name", ingress.Metadata.Name)
+		e.String("namespace", ingress.Metadata.Namespace)
+	})
+
+	// 如果 IsEnforcementEnabled 返回 false，说明不需要检查，直接通过.
+	if !settings.IsEnforcementEnabled() {
+		return kubewarden.AcceptRequest()
+	}
+
+	// 在任何 Service 查询之前处理豁免规则
+	if exempt, response, err := handleExemptions(ingress.Metadata, settings); exempt {
+		return response, err
+	}
+
+	// 变更模式下先用 Service 规范化后端端口，再校验规范化后的对象
+	lookup := newServiceLookup(settings)
+	mutated := settings.NormalizeBackendPorts && normalizeBackendPorts(ingress, lookup)
+
+	// 检查全部 Service（包括控制器注解引用的 Service 与 Secret）及可选的 resource 后端、TLS Secret、IngressClass、允许的主机与路径冲突，
+	// 汇总所有问题后一次性返回。validate_only_changed_backends 模式下，UPDATE 前已存在的引用单独检查，
+	// 其问题写入 preExisting，只报告、不阻止请求
+	report := newValidationReport()
+	preExisting := newValidationReport()
+	oldIngress := decodeOldObject(settings, validationRequest, getIngress)
+	namespace := ingress.Metadata.Namespace
+	annotationRefs, annotationProblems := extractAnnotationReferences(ingress, settings)
+	oldAnnotationRefs, oldAnnotationProblems := extractAnnotationReferences(oldIngress, settings)
+	checkChangedSeparately(annotationProblems, oldAnnotationProblems, stringIdentity, report, preExisting, addViolations)
+	checkChangedSeparately(
+		append(extractServiceReferences(ingress, settings), annotationRefs.Services...),
+		append(extractServiceReferences(oldIngress, settings), oldAnnotationRefs.Services...),
+		serviceReferenceIdentity, report, preExisting,
+		func(subset []serviceReference, r *validationReport) {
+			checkServiceReferences(settings, lookup, subset, r)
+		})
+	checkChangedSeparately(annotationRefs.Secrets, oldAnnotationRefs.Secrets, annotationSecretIdentity, report, preExisting,
+		func(subset []annotationSecretReference, r *validationReport) {
+			checkAnnotationSecrets(settings, subset, r)
+		})
+	checkChangedSeparately(annotationRefs.Objects, oldAnnotationRefs.Objects, objectReferenceIdentity, report, preExisting,
+		func(subset []objectReference, r *validationReport) { checkObjectReferences(settings, subset, r) })
+	if settings.ValidateResourceBackends {
+		checkChangedSeparately(extractResourceBackendReferences(ingress), extractResourceBackendReferences(oldIngress),
+			resourceBackendReference.key, report, preExisting,
+			func(subset []resourceBackendReference, r *validationReport) {
+				checkResourceBackends(namespace, subset, settings, r)
+			})
+	}
+	if settings.EnforceTLSSecretExists {
+		checkIngressTLS(ingress, oldIngress, settings, report, preExisting)
+	}
+	if settings.ValidateIngressClass {
+		checkIngressClass(ingress, settings, report)
+	}
+	if settings.AllowedHostsAnnotation != "" || len(settings.AllowedHosts) > 0 {
+		checkAllowedHosts(ingress, settings, report)
+	}
+	if settings.DetectHostPathCollisions {
+		checkHostPathCollisions(ingress, report)
+	}
+	report.addPreExistingReport(preExisting)
+	// 只有通过全部校验的对象才返回规范化结果；有问题时按原对象响应
+	if mutated && report.empty() && report.preExistingMessage() == "" {
+		report.logWarnings(validationRequest.Request.Uid, ingress.Metadata)
+		return kubewarden.MutateRequest(ingress)
+	}
+	return respondWithReport(settings, report, validationRequest.Request.Uid, ingress.Metadata)
+}
+
+// handleExemptions 在任何后端查询之前评估豁免规则。
+// 第一个返回值为 true 时，调用方应直接返回给出的响应。
+func handleExemptions(meta *metav1.ObjectMeta, settings Settings) (bool, []byte, error) {
+	reason, err := exemptionReason(meta, settings)
+	if err != nil {
+		response, respErr := applyFailurePolicy(settings, fmt.Sprintf("Cannot evaluate exemptions: %s", err))
+		return true, response, respErr
+	}
+	if reason == "" {
+		return false, nil, nil
+	}
+	logger.DebugWithFields("object is exempt from validation", func(e onelog.Entry) {
+		e.String("name", meta.Name)
+		e.String("namespace", meta.Namespace)
+		e.String("reason", reason)
+	})
+	response, respErr := kubewarden.AcceptRequest()
+	return true, response, respErr
+}
+
+// respondWithReport 根据校验报告与 enforcement_mode、failure_policy 生成最终响应。
+func respondWithReport(settings Settings, report *validationReport, uid string, meta *metav1.ObjectMeta) ([]byte, error) {
+	report.logWarnings(uid, meta)
+	if report.empty() {
+		// 全部校验通过；UPDATE 前已存在的问题只在响应信息中说明
+		if message := report.preExistingMessage(); message != "" {
+			return acceptRequestWithMessage(message)
+		}
+		return kubewarden.AcceptRequest()
+	}
+	// warn 模式下只记录问题并放行，便于在切换到 deny 之前评估影响
+	if settings.EffectiveEnforcementMode() == enforcementModeWarn {
+		report.log(uid, meta)
+		return kubewarden.AcceptRequest()
+	}
+	// 只有基础设施错误时，由 failure_policy 决定放行还是拒绝
+	if !report.hasViolations() {
+		return applyFailurePolicy(settings, report.message())
+	}
+	return kubewarden.RejectRequest(
+		kubewarden.Message(report.message()),
+		kubewarden.NoCode)
+}
+
+// applyFailurePolicy 在无法完成校验（host call 失败）时按 failure_policy 放行或拒绝，
+// 并在响应信息中记录所采取的行为。
+func applyFailurePolicy(settings Settings, message string) ([]byte, error) {
+	policy := settings.EffectiveFailurePolicy()
+	if policy == failurePolicyIgnore {
+		message = fmt.Sprintf("%s (failure_policy=%s: request accepted without verification)", message, policy)
+		logger.WarnWithFields("backend verification failed, accepting request", func(e onelog.Entry) {
+			e.String("failure_policy", policy)
+			e.String("error", message)
+		})
+		return acceptRequestWithMessage(message)
+	}
+	return kubewarden.RejectRequest(
+		kubewarden.Message(fmt.Sprintf("%s (failure_policy=%s: request rejected)", message, policy)),
+		kubewarden.NoCode)
+}
+
+// acceptRequestWithMessage 放行请求，同时在响应中附带说明信息。
+func acceptRequestWithMessage(message string) ([]byte, error) {
+	return json.Marshal(kubewarden_protocol.ValidationResponse{
+		Accepted: true,
+		Message:  &message,
+	})
+}
+
+// getIngress 从 RAW JSON 中解析出 Ingress 对象。
+func getIngress(rawJSON json.RawMessage) (*networkingv1.Ingress, error) {
+	if len(rawJSON) == 0 {
+		return nil, errors.New("empty ingress object")
+	}
+	ing := &networkingv1.Ingress{}
+	if err := json.Unmarshal(rawJSON, ing); err != nil {
+		return nil, err
+	}
+	return ing, nil
+}
+
+// serviceReference 描述 Ingress 中对某个 Service 后端的一次引用及其所在位置。
+type serviceReference struct {
+	// Namespace 为 Service 所在的命名空间。
+	Namespace string
+	// Name 为引用的 Service 名称。
+	Name string
+	// Port 为引用的 Service 端口，可能为 nil。
+	Port *networkingv1.ServiceBackendPort
+	// TargetPort 为引用的 Service 端口或其 targetPort，可能为 nil，目前只有 OpenShift Route 使用。
+	TargetPort *networkingv1.ServiceBackendPort
+	// Location 描述引用出现的位置，例如 defaultBackend 或 rules[0].http.paths[1]。
+	Location string
+}
+
+// extractServiceReferences 按出现顺序收集 Ingress 中所有 Service 类型的后端引用（不去重）。
+// 由 AWS Load Balancer Controller 注解检查负责的 use-annotation 后端不是 Service 引用，不会返回。
+func extractServiceReferences(ing *networkingv1.Ingress, settings Settings) []serviceReference {
+	if ing == nil || ing.Spec == nil {
+		return nil
+	}
+
+	var refs []serviceReference
+	svcName := extractServiceNameFromBackend(ing.Spec.DefaultBackend)
+	if svcName != "" && !isClaimedALBActionBackend(ing.Spec.DefaultBackend, settings) {
+		refs = append(refs, serviceReference{
+			Namespace: ing.Metadata.Namespace,
+			Name:      svcName,
+			Port:      ing.Spec.DefaultBackend.Service.Port,
+			Location:  "defaultBackend",
+		})
+	}
+	for i, rule := range ing.Spec.Rules {
+		if rule == nil || rule.HTTP == nil {
+			continue
+		}
+		for j, path := range rule.HTTP.Paths {
+			if path == nil {
+				continue
+			}
+			svcName := extractServiceNameFromBackend(path.Backend)
+			if svcName == "" || isClaimedALBActionBackend(path.Backend, settings) {
+				continue
+			}
+			refs = append(refs, serviceReference{
+				Namespace: ing.Metadata.Namespace,
+				Name:      svcName,
+				Port:      path.Backend.Service.Port,
+				Location:  formatPathLocation(i, j, rule.Host, path.Path),
+			})
+		}
+	}
+	return refs
+}
+
+// formatPathLocation 生成路径规则的位置描述，例如 rules[0].http.paths[1] (host 'foo.bar.com', path '/bar')。
+func formatPathLocation(ruleIdx, pathIdx int, host, path string) string {
+	location := fmt.Sprintf("rules[%d].http.paths[%d]", ruleIdx, pathIdx)
+	details := make([]string, 0, 2)
+	if host != "" {
+		details = append(details, fmt.Sprintf("host '%s'", host))
+	}
+	if path != "" {
+		details = append(details, fmt.Sprintf("path '%s'", path))
+	}
+	if len(details) == 0 {
+		return location
+	}
+	return fmt.Sprintf("%s (%s)", location, strings.Join(details, ", "))
+}
+
+// groupServiceReferences 按 Service（命名空间 + 名称）对引用进行分组，返回排序后的键。
+// host call 与报告中的问题都按此顺序产生，与 map 遍历顺序和后端在 Spec 中的位置无关，
+// 保证基于录制回放的 e2e 测试与审计输出稳定；同一 Service 的引用保持在 Spec 中的顺序。
+func groupServiceReferences(refs []serviceReference) ([]string, map[string][]serviceReference) {
+	keys := make([]string, 0, len(refs))
+	byKey := make(map[string][]serviceReference, len(refs))
+	for _, ref := range refs {
+		key := ref.Namespace + "/" + ref.Name
+		if _, ok := byKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], ref)
+	}
+	sort.Strings(keys)
+	return keys, byKey
+}
+
+// checkServiceReferences 检查所有被引用的 Service 及其端口，不在第一个错误处停止。
+func checkServiceReferences(settings Settings, lookup *serviceLookup, refs []serviceReference, report *validationReport) {
+	checkServiceReferencesWithFallback(settings, lookup, refs, nil, report)
+}
+
+// missingServiceFallback 在引用的 Service 不存在时调用，返回 true 表示这些引用由其他对象满足
+// （例如 Istio ServiceEntry），不再报告 Service 缺失。
+type missingServiceFallback func(namespace, name string, refs []serviceReference) bool
+
+// checkServiceReferencesWithFallback 与 checkServiceReferences 相同，但 Service 不存在时先询问 fallback。
+func checkServiceReferencesWithFallback(
+	settings Settings, lookup *serviceLookup, refs []serviceReference, fallback missingServiceFallback, report *validationReport,
+) {
+	keys, byKey := groupServiceReferences(refs)
+	for _, key := range keys {
+		svcRefs := byKey[key]
+		namespace, name := svcRefs[0].Namespace, svcRefs[0].Name
+		service, serviceOK, serviceErr := lookup.get(namespace, name)
+		if serviceErr != nil {
+			report.addHostError(fmt.Sprintf("Service '%s'", name), formatReferenceLocations(svcRefs), serviceErr)
+			continue
+		}
+		if !serviceOK {
+			if fallback != nil && fallback(namespace, name, svcRefs) {
+				continue
+			}
+			report.addMissingService(namespace, name, svcRefs)
+			continue
+		}
+		checkExternalNameDestination(settings, service, svcRefs, report)
+		for _, ref := range svcRefs {
+			if !servicePortExists(service, ref.Port) {
+				report.addMissingPort(service, ref)
+			}
+			if !serviceTargetPortExists(service, ref.TargetPort) {
+				report.addMissingTargetPort(service, ref)
+			}
+		}
+		if settings.IsReadyEndpointsCheckEnabled() {
+			checkReadyEndpoints(settings, service, svcRefs, report)
+		}
+	}
+}
+
+// extractServiceNameFromBackend 从后端配置中提取服务名称。
+func extractServiceNameFromBackend(backend *networkingv1.IngressBackend) string {
+	if backend == nil || backend.Service == nil || backend.Service.Name == nil {
+		return ""
+	}
+	if *backend.Service.Name == "" {
+		return ""
+	}
+	return *backend.Service.Name
+}
+
+// serviceExists 调用 Kubewarden Capabilities 检查 Service 是否存在，
+// 存在时同时返回解码后的 Service 对象，供后续端口校验使用。
+func serviceExists(namespace string, settings Settings, serviceName string) (*corev1.Service, bool, error) {
+	// 参数验证
+	if namespace == "" {
+		return nil, false, errors.New("namespace cannot be empty")
+	}
+	if serviceName == "" {
+		return nil, false, errors.New("service name cannot be empty")
+	}
+
+	respBytes, err := getResource(getResourceRequest{
+		APIVersion:   "v1",
+		Kind:         "Service",
+		Namespace:    namespace,
+		Name:         serviceName,
+		DisableCache: settings.DisableCache,
+	})
+	if err != nil {
+		if errors.Is(err, ErrResourceNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if len(respBytes) == 0 {
+		return nil, false, nil
+	}
+
+	service := &corev1.Service{}
+	if err := json.Unmarshal(respBytes, service); err != nil {
+		return nil, false, fmt.Errorf("cannot decode Service '%s': %w", serviceName, err)
+	}
+	return service, true, nil
+}
+
+// servicePortExists 判断 backend 引用的端口（数字或名称）是否在 Service 的 spec.ports 中声明。
+// ExternalName 类型的 Service 由控制器直接转发到外部地址，不校验端口。
+func servicePortExists(service *corev1.Service, port *networkingv1.ServiceBackendPort) bool {
+	if port == nil || (port.Number == 0 && port.Name == "") {
+		return true
+	}
+	if service == nil || service.Spec == nil {
+		return false
+	}
+	if service.Spec.Type == "ExternalName" {
+		return true
+	}
+	for _, sp := range service.Spec.Ports {
+		if sp == nil {
+			continue
+		}
+		if port.Number != 0 && sp.Port != nil && *sp.Port == port.Number {
+			return true
+		}
+		if port.Name != "" && sp.Name == port.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// serviceTargetPortExists 判断 targetPort 是否匹配 Service 的某个端口：端口号匹配 port 或数字 targetPort，
+// 端口名匹配端口名或命名 targetPort，与 OpenShift router 解析 spec.port.targetPort 的方式一致。
+// ExternalName 类型的 Service 不校验端口。
+func serviceTargetPortExists(service *corev1.Service, targetPort *networkingv1.ServiceBackendPort) bool {
+	if targetPort == nil || (targetPort.Number == 0 && targetPort.Name == "") {
+		return true
+	}
+	if servicePortExists(service, targetPort) {
+		return true
+	}
+	if service == nil || service.Spec == nil {
+		return false
+	}
+	for _, sp := range service.Spec.Ports {
+		if sp == nil || sp.TargetPort == nil {
+			continue
+		}
+		switch sp.TargetPort.Type {
+		case intstr.Int64:
+			if targetPort.Number != 0 && sp.TargetPort.Int64Val == int64(targetPort.Number) {
+				return true
+			}
+		case intstr.String:
+			if targetPort.Name != "" && sp.TargetPort.StrVal == targetPort.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// intOrStringPort 是 CRD 与注解中常见的 int-or-string 端口字段，可以是端口号、数字字符串或端口名。
+type intOrStringPort struct {
+	networkingv1.ServiceBackendPort
+}
+
+// UnmarshalJSON 解析数字或字符串形式的端口。
+func (p *intOrStringPort) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '"' {
+		return json.Unmarshal(data, &p.Number)
+	}
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if number, err := strconv.ParseInt(value, 10, 32); err == nil {
+		p.Number = int32(number)
+		return nil
+	}
+	p.Name = value
+	return nil
+}
+
+// MarshalJSON 输出端口号或端口名，与 UnmarshalJSON 对称。
+func (p intOrStringPort) MarshalJSON() ([]byte, error) {
+	if p.Number != 0 {
+		return json.Marshal(p.Number)
+	}
+	return json.Marshal(p.Name)
+}
+
+// formatBackendPort 将 backend 端口格式化为可读字符串，例如 8080 或 'http'。
+func formatBackendPort(port *networkingv1.ServiceBackendPort) string {
+	if port == nil {
+		return "<unset>"
+	}
+	if port.Number != 0 {
+		return fmt.Sprintf("%d", port.Number)
+	}
+	return fmt.Sprintf("'%s'", port.Name)
+}
+
+// formatServicePorts 列出 Service 实际声明的端口，例如 80/TCP (http), 443/TCP (https)。
+func formatServicePorts(service *corev1.Service) string {
+	if service == nil || service.Spec == nil || len(service.Spec.Ports) == 0 {
+		return "none"
+	}
+	ports := make([]string, 0, len(service.Spec.Ports))
+	for _, sp := range service.Spec.Ports {
+		if sp == nil || sp.Port == nil {
+			continue
+		}
+		protocol := sp.Protocol
+		if protocol == "" {
+			protocol = "TCP"
+		}
+		desc := fmt.Sprintf("%d/%s", *sp.Port, protocol)
+		if sp.Name != "" {
+			desc += fmt.Sprintf(" (%s)", sp.Name)
+		}
+		ports = append(ports, desc)
+	}
+	if len(ports) == 0 {
+		return "none"
+	}
+	return strings.Join(ports, ", ")
+}
@@ -0,0 +1,912 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	onelog "github.com/francoispqt/onelog"
+	corev1 "github.com/kubewarden/k8s-objects/api/core/v1"
+	networkingv1 "github.com/kubewarden/k8s-objects/api/networking/v1"
+	metav1 "github.com/kubewarden/k8s-objects/apimachinery/pkg/apis/meta/v1"
+	kubewarden_protocol "github.com/kubewarden/policy-sdk-go/protocol"
+	kubewarden_testing "github.com/kubewarden/policy-sdk-go/testing"
+)
+
+// mockResourceKey 生成模拟资源的索引键，集群级资源的 namespace 为空。
+func mockResourceKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// defaultMockResources 返回所有测试共用的模拟资源。
+func defaultMockResources() map[string]string {
+	return map[string]string{
+		// Namespace 为集群级资源，带有用于选择器测试的标签
+		mockResourceKey("Namespace", "", "default"): `{"kind":"Namespace","apiVersion":"v1","metadata":{"name":"default","labels":{"team":"platform"}}}`,
+		mockResourceKey("Namespace", "", "sandbox"): `{"kind":"Namespace","apiVersion":"v1","metadata":{"name":"sandbox","labels":{"env":"dev"}}}`,
+		mockResourceKey("Service", "default", "my-service"): `{"kind":"Service","apiVersion":"v1","metadata":{"name":"my-service","namespace":"default"},` +
+			`"spec":{"type":"ClusterIP","ports":[{"name":"http","port":80,"protocol":"TCP"},` +
+			`{"name":"web","port":8080,"protocol":"TCP"},{"name":"metrics","port":9090,"protocol":"TCP"}]}}`,
+		mockResourceKey("Service", "default", "external-service"): `{"kind":"Service","apiVersion":"v1","metadata":{"name":"external-service","namespace":"default"},` +
+			`"spec":{"type":"ExternalName","externalName":"example.com"}}`,
+		mockResourceKey("IngressClass", "", "nginx"): `{"kind":"IngressClass","apiVersion":"networking.k8s.io/v1",` +
+			`"metadata":{"name":"nginx","annotations":{"ingressclass.kubernetes.io/is-default-class":"true"}},` +
+			`"spec":{"controller":"k8s.io/ingress-nginx"}}`,
+		mockResourceKey("IngressClass", "", "traefik"): `{"kind":"IngressClass","apiVersion":"networking.k8s.io/v1",` +
+			`"metadata":{"name":"traefik"},"spec":{"controller":"traefik.io/ingress-controller"}}`,
+		mockResourceKey("Service", "shared", "shared-service"): `{"kind":"Service","apiVersion":"v1","metadata":{"name":"shared-service","namespace":"shared"},` +
+			`"spec":{"type":"ClusterIP","ports":[{"name":"http","port":80,"protocol":"TCP"}]}}`,
+		mockResourceKey("Service", "restricted", "private-service"): `{"kind":"Service","apiVersion":"v1","metadata":{"name":"private-service","namespace":"restricted"},` +
+			`"spec":{"type":"ClusterIP","ports":[{"name":"http","port":80,"protocol":"TCP"}]}}`,
+		// shared 命名空间允许 default 中的 HTTPRoute 引用任意 Service
+		mockResourceKey("ReferenceGrant", "shared", "allow-default"): `{"kind":"ReferenceGrant","apiVersion":"gateway.networking.k8s.io/v1beta1",` +
+			`"metadata":{"name":"allow-default","namespace":"shared"},"spec":{` +
+			`"from":[{"group":"gateway.networking.k8s.io","kind":"HTTPRoute","namespace":"default"}],` +
+			`"to":[{"group":"","kind":"Service"}]}}`,
+		// dGVzdA== 为 "test" 的 base64 编码
+		mockResourceKey("Secret", "default", "tls-secret"): `{"kind":"Secret","apiVersion":"v1","metadata":{"name":"tls-secret","namespace":"default"},` +
+			`"type":"kubernetes.io/tls","data":{"tls.crt":"dGVzdA==","tls.key":"dGVzdA=="}}`,
+		mockResourceKey("Secret", "default", "opaque-secret"): `{"kind":"Secret","apiVersion":"v1","metadata":{"name":"opaque-secret","namespace":"default"},` +
+			`"type":"Opaque","data":{"tls.crt":"dGVzdA==","tls.key":"dGVzdA=="}}`,
+		mockResourceKey("Secret", "default", "empty-tls-secret"): `{"kind":"Secret","apiVersion":"v1","metadata":{"name":"empty-tls-secret","namespace":"default"},` +
+			`"type":"kubernetes.io/tls","data":{"tls.crt":"dGVzdA=="}}`,
+	}
+}
+
+// defaultMockErrors 返回按名称模拟的 host call 错误。
+func defaultMockErrors() map[string]string {
+	return map[string]string{
+		"unreachable-service": "connection refused",
+		"forbidden-service":   `services "forbidden-service" is forbidden: User "policy-server" cannot get resource "services"`,
+	}
+}
+
+// 模拟 host capabilities 调用的响应。
+type mockWapcClient struct {
+	// resources 以 mockResourceKey 为键保存资源 JSON
+	resources map[string]string
+	// errors 以资源名称为键保存需要返回的错误
+	errors map[string]string
+	// untrustedReason 非空时，证书链校验返回不可信
+	untrustedReason string
+}
+
+func (c *mockWapcClient) HostCall(binding, namespace, operation string, payload []byte) ([]byte, error) {
+	if binding == "kubewarden" && namespace == "kubernetes" && operation == "get_resource" {
+		// 解析请求
+		req := map[string]interface{}{}
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return nil, err
+		}
+		apiVersion, _ := req["api_version"].(string)
+		kind, _ := req["kind"].(string)
+		ns, _ := req["namespace"].(string)
+		name, _ := req["name"].(string)
+
+		if msg, ok := c.errors[name]; ok {
+			return nil, errors.New(msg)
+		}
+		if resource, ok := c.resources[mockResourceKey(kind, ns, name)]; ok {
+			return []byte(resource), nil
+		}
+		// 对于不存在的资源返回与 kwctl 相同的错误信息
+		return nil, fmt.Errorf("Cannot find %s/%s named '%s' inside of namespace 'Some(\"%s\")'", apiVersion, kind, name, ns)
+	}
+	if binding == "kubewarden" && namespace == "kubernetes" && operation == "list_resources_by_namespace" {
+		req := map[string]interface{}{}
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return nil, err
+		}
+		kind, _ := req["kind"].(string)
+		ns, _ := req["namespace"].(string)
+		selector, _ := req["label_selector"].(string)
+		if msg, ok := c.errors[kind]; ok {
+			return nil, errors.New(msg)
+		}
+		return c.listMatching(kind+"/"+ns+"/", selector), nil
+	}
+	if binding == "kubewarden" && namespace == "kubernetes" && operation == "list_resources_all" {
+		req := map[string]interface{}{}
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return nil, err
+		}
+		kind, _ := req["kind"].(string)
+		if msg, ok := c.errors[kind]; ok {
+			return nil, errors.New(msg)
+		}
+		return c.list(kind + "/"), nil
+	}
+	if binding == "kubewarden" && namespace == "crypto" && operation == "v1/is_certificate_trusted" {
+		if c.untrustedReason != "" {
+			return json.Marshal(map[string]interface{}{"trusted": false, "reason": c.untrustedReason})
+		}
+		return []byte(`{"trusted":true}`), nil
+	}
+	return nil, errors.New("unexpected host call")
+}
+
+// list 按键名顺序返回所有以 prefix 开头的模拟资源组成的 List 对象。
+func (c *mockWapcClient) list(prefix string) []byte {
+	return c.listMatching(prefix, "")
+}
+
+// listMatching 与 list 相同，但只返回标签满足 key=value 形式选择器的资源。
+func (c *mockWapcClient) listMatching(prefix, selector string) []byte {
+	keys := make([]string, 0, len(c.resources))
+	for key := range c.resources {
+		if strings.HasPrefix(key, prefix) && mockLabelsMatch(c.resources[key], selector) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	items := make([]json.RawMessage, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, json.RawMessage(c.resources[key]))
+	}
+	list, _ := json.Marshal(map[string]interface{}{"items": items})
+	return list
+}
+
+// mockLabelsMatch 判断资源的标签是否满足以逗号分隔的 key=value 选择器，空选择器匹配全部资源。
+func mockLabelsMatch(raw, selector string) bool {
+	if selector == "" {
+		return true
+	}
+	var object struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(raw), &object); err != nil {
+		return false
+	}
+	for _, requirement := range strings.Split(selector, ",") {
+		parts := strings.SplitN(requirement, "=", 2)
+		if len(parts) != 2 || object.Metadata.Labels[parts[0]] != parts[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// newMockWapcClient 创建带默认资源的模拟客户端，extra 中的资源会覆盖默认值。
+func newMockWapcClient(extra map[string]string) *mockWapcClient {
+	resources := defaultMockResources()
+	for key, value := range extra {
+		resources[key] = value
+	}
+	return &mockWapcClient{
+		resources: resources,
+		errors:    defaultMockErrors(),
+	}
+}
+
+// mockResources 以 mockResourceKey 为键收集单个测试额外需要的模拟资源，可以直接传给 newMockWapcClient。
+// 资源由 k8s-objects 类型或字段 map 序列化得到，因此总是合法的 JSON。
+type mockResources map[string]string
+
+// add 序列化 object，并以 kind、namespace 与 name 为键保存。
+func (m mockResources) add(kind, namespace, name string, object interface{}) mockResources {
+	raw, err := json.Marshal(object)
+	if err != nil {
+		panic(fmt.Sprintf("cannot marshal mock %s %s/%s: %v", kind, namespace, name, err))
+	}
+	m[mockResourceKey(kind, namespace, name)] = string(raw)
+	return m
+}
+
+// addService 添加一个 Service。
+func (m mockResources) addService(namespace, name string, spec *corev1.ServiceSpec) mockResources {
+	return m.add("Service", namespace, name, &corev1.Service{
+		APIVersion: "v1",
+		Kind:       "Service",
+		Metadata:   &metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       spec,
+	})
+}
+
+// addSecret 添加一个不含数据、只用于存在性检查的 Secret。
+func (m mockResources) addSecret(namespace, name, secretType string) mockResources {
+	return m.add("Secret", namespace, name, &corev1.Secret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   &metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Type:       secretType,
+	})
+}
+
+// addNamespace 添加一个 Namespace。
+func (m mockResources) addNamespace(name string, labels, annotations map[string]string) mockResources {
+	return m.add("Namespace", "", name, &corev1.Namespace{
+		APIVersion: "v1",
+		Kind:       "Namespace",
+		Metadata:   &metav1.ObjectMeta{Name: name, Labels: labels, Annotations: annotations},
+	})
+}
+
+// addIngress 添加一个已存在的 Ingress，键取自 ingress.Metadata。
+func (m mockResources) addIngress(ingress *networkingv1.Ingress) mockResources {
+	ingress.APIVersion = "networking.k8s.io/v1"
+	ingress.Kind = "Ingress"
+	return m.add("Ingress", ingress.Metadata.Namespace, ingress.Metadata.Name, ingress)
+}
+
+// addObject 添加一个 k8s-objects 中没有对应类型的对象（例如 CRD 或 EndpointSlice），
+// fields 为 metadata 之外的顶层字段。
+func (m mockResources) addObject(
+	apiVersion, kind string, metadata *metav1.ObjectMeta, fields map[string]interface{},
+) mockResources {
+	object := map[string]interface{}{"apiVersion": apiVersion, "kind": kind, "metadata": metadata}
+	for key, value := range fields {
+		object[key] = value
+	}
+	return m.add(kind, metadata.Namespace, metadata.Name, object)
+}
+
+func setupTestEnv() {
+	// 设置全局 host 的模拟客户端
+	host.Client = newMockWapcClient(nil)
+}
+
+// runValidation 使用默认的模拟客户端执行 validate 并解析响应。
+func runValidation(t *testing.T, ingress *networkingv1.Ingress, settings *Settings) kubewarden_protocol.ValidationResponse {
+	t.Helper()
+	setupTestEnv()
+	return validateWithClient(t, ingress, settings)
+}
+
+// validateWithClient 使用当前设置的 host.Client 执行 validate 并解析响应。
+func validateWithClient(t *testing.T, ingress *networkingv1.Ingress, settings *Settings) kubewarden_protocol.ValidationResponse {
+	t.Helper()
+	payload, err := kubewarden_testing.BuildValidationRequest(ingress, settings)
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	responsePayload, err := Validate(payload)
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	var response kubewarden_protocol.ValidationResponse
+	if err = json.Unmarshal(responsePayload, &response); err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	return response
+}
+
+func TestEmptySettingsLeadsToApproval(t *testing.T) {
+	setupTestEnv()
+	settings := Settings{} // 默认值为 true，应该检查 service
+	ingress := networkingv1.Ingress{
+		Metadata: &metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+		},
+		Spec: &networkingv1.IngressSpec{
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: strPtr("my-service"), // 使用存在的服务
+				},
+			},
+		},
+	}
+
+	payload, err := kubewarden_testing.BuildValidationRequest(&ingress, &settings)
+	if err != nil {
+		t.Errorf("Unexpected error: %+v", err)
+	}
+
+	responsePayload, err := Validate(payload)
+	if err != nil {
+		t.Errorf("Unexpected error: %+v", err)
+	}
+
+	var response kubewarden_protocol.ValidationResponse
+	if err = json.Unmarshal(responsePayload, &response); err != nil {
+		t.Errorf("Unexpected error: %+v", err)
+	}
+
+	if response.Accepted != true {
+		t.Errorf("Unexpected rejection: msg %s - code %d", *response.Message, *response.Code)
+	}
+}
+
+func TestApprovalWithDisabledValidation(t *testing.T) {
+	setupTestEnv()
+	settings := Settings{
+		EnforceServiceExists: false, // 设置为 false 来禁用验证
+	}
+	ingress := networkingv1.Ingress{
+		Metadata: &metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+		},
+		Spec: &networkingv1.IngressSpec{
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: strPtr("non-existent-service"),
+					Port: &networkingv1.ServiceBackendPort{
+						Number: 80,
+					},
+				},
+			},
+		},
+	}
+
+	payload, err := kubewarden_testing.BuildValidationRequest(&ingress, &settings)
+	if err != nil {
+		t.Errorf("Unexpected error: %+v", err)
+	}
+
+	responsePayload, err := Validate(payload)
+	if err != nil {
+		t.Errorf("Unexpected error: %+v", err)
+	}
+
+	var response kubewarden_protocol.ValidationResponse
+	if err = json.Unmarshal(responsePayload, &response); err != nil {
+		t.Errorf("Unexpected error: %+v", err)
+	}
+
+	if response.Accepted != true {
+		t.Error("Unexpected rejection when validation is disabled")
+	}
+}
+
+func TestApprovalWhenServicesExist(t *testing.T) {
+	setupTestEnv()
+	settings := Settings{
+		EnforceServiceExists: true, // 启用验证，检查 service 是否存在
+	}
+	ingress := networkingv1.Ingress{
+		Metadata: &metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+		},
+		Spec: &networkingv1.IngressSpec{
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: strPtr("my-service"), // 此服务在 mock 中存在
+				},
+			},
+		},
+	}
+
+	payload, err := kubewarden_testing.BuildValidationRequest(&ingress, &settings)
+	if err != nil {
+		t.Errorf("Unexpected error: %+v", err)
+	}
+
+	responsePayload, err := Validate(payload)
+	if err != nil {
+		t.Errorf("Unexpected error: %+v", err)
+	}
+
+	var response kubewarden_protocol.ValidationResponse
+	if err = json.Unmarshal(responsePayload, &response); err != nil {
+		t.Errorf("Unexpected error: %+v", err)
+	}
+
+	if response.Accepted != true {
+		t.Error("Unexpected rejection when services exist")
+	}
+}
+
+func TestRejectionWhenServiceNotFound(t *testing.T) {
+	setupTestEnv()
+	settings := Settings{
+		EnforceServiceExists: true, // 启用验证，检查 service 是否存在
+	}
+	ingress := networkingv1.Ingress{
+		Metadata: &metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+		},
+		Spec: &networkingv1.IngressSpec{
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: strPtr("non-existent-service"), // 此服务在 mock 中不存在
+				},
+			},
+		},
+	}
+
+	payload, err := kubewarden_testing.BuildValidationRequest(&ingress, &settings)
+	if err != nil {
+		t.Errorf("Unexpected error: %+v", err)
+	}
+
+	responsePayload, err := Validate(payload)
+	if err != nil {
+		t.Errorf("Unexpected error: %+v", err)
+	}
+
+	var response kubewarden_protocol.ValidationResponse
+	if err = json.Unmarshal(responsePayload, &response); err != nil {
+		t.Errorf("Unexpected error: %+v", err)
+	}
+
+	if response.Accepted != false {
+		t.Error("Expected rejection when service does not exist")
+	}
+
+	expectedMessage := "Service 'non-existent-service' does not exist in namespace 'default' (referenced by defaultBackend)"
+	if response.Message == nil {
+		t.Fatalf("expected response to have a message")
+	}
+	if *response.Message != expectedMessage {
+		t.Errorf("Got '%s' instead of '%s'", *response.Message, expectedMessage)
+	}
+}
+
+func TestEmptyIngressValidation(t *testing.T) {
+	// 测试没有任何 backend 配置的 Ingress
+	// 创建一个没有 DefaultBackend 的 Ingress
+	ingress := networkingv1.Ingress{
+		Metadata: &metav1.ObjectMeta{
+			Name:      "empty-ingress",
+			Namespace: "default",
+		},
+		Spec: &networkingv1.IngressSpec{
+			// 没有 DefaultBackend
+		},
+	}
+
+	// 使用默认设置，期望通过验证
+	settings := Settings{}
+	payload, err := kubewarden_testing.BuildValidationRequest(&ingress, &settings)
+	if err != nil {
+		t.Errorf("Unexpected error: %+v", err)
+	}
+
+	responsePayload, err := Validate(payload)
+	if err != nil {
+		t.Errorf("Unexpected error: %+v", err)
+	}
+
+	var response kubewarden_protocol.ValidationResponse
+	if err = json.Unmarshal(responsePayload, &response); err != nil {
+		t.Errorf("Unexpected error: %+v", err)
+	}
+
+	if response.Accepted != true {
+		t.Errorf("Unexpected rejection for empty ingress: msg %s - code %d", *response.Message, *response.Code)
+	}
+}
+
+func TestComplexIngressRules(t *testing.T) {
+	setupTestEnv()
+	settings := Settings{
+		EnforceServiceExists: true, // 启用验证，检查 service 是否存在
+	}
+	ingress := networkingv1.Ingress{
+		Metadata: &metav1.ObjectMeta{
+			Name:      "complex-ingress",
+			Namespace: "default",
+		},
+		Spec: &networkingv1.IngressSpec{
+			// 1. 默认后端
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: strPtr("my-service"), // 存在的服务
+					Port: &networkingv1.ServiceBackendPort{
+						Number: 80,
+					},
+				},
+			},
+			// 2. 多个路径规则
+			Rules: []*networkingv1.IngressRule{
+				{
+					Host: "foo.bar.com",
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []*networkingv1.HTTPIngressPath{
+							{
+								Path:     "/foo",
+								PathType: strPtr("Prefix"),
+								Backend: &networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: strPtr("my-service"),
+										Port: &networkingv1.ServiceBackendPort{
+											Number: 8080,
+										},
+									},
+								},
+							},
+							{
+								Path:     "/bar",
+								PathType: strPtr("Prefix"),
+								Backend: &networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: strPtr("non-existent-service"),
+										Port: &networkingv1.ServiceBackendPort{
+											Number: 8080,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Host: "another.example.com",
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []*networkingv1.HTTPIngressPath{
+							{
+								Path:     "/",
+								PathType: strPtr("Prefix"),
+								Backend: &networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: strPtr("my-service"),
+										Port: &networkingv1.ServiceBackendPort{
+											Number: 9090,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	payload, err := kubewarden_testing.BuildValidationRequest(&ingress, &settings)
+	if err != nil {
+		t.Errorf("Unexpected error: %+v", err)
+	}
+
+	responsePayload, err := Validate(payload)
+	if err != nil {
+		t.Errorf("Unexpected error: %+v", err)
+	}
+
+	var response kubewarden_protocol.ValidationResponse
+	if err = json.Unmarshal(responsePayload, &response); err != nil {
+		t.Errorf("Unexpected error: %+v", err)
+	}
+
+	// 因为包含不存在的服务，应该被拒绝
+	if response.Accepted {
+		t.Error("Expected complex Ingress to be rejected due to non-existent service")
+	}
+
+	expectedMessage := "Service 'non-existent-service' does not exist in namespace 'default' " +
+		"(referenced by rules[0].http.paths[1] (host 'foo.bar.com', path '/bar'))"
+	if response.Message == nil {
+		t.Fatalf("expected response to have a message")
+	}
+	if *response.Message != expectedMessage {
+		t.Errorf("Got '%s' instead of '%s'", *response.Message, expectedMessage)
+	}
+}
+
+// validateIngressWithPort 构造一个只有默认后端的 Ingress，并返回 validate 的响应。
+func validateIngressWithPort(t *testing.T, serviceName string, port *networkingv1.ServiceBackendPort) kubewarden_protocol.ValidationResponse {
+	t.Helper()
+	setupTestEnv()
+	settings := Settings{
+		EnforceServiceExists: true,
+	}
+	ingress := networkingv1.Ingress{
+		Metadata: &metav1.ObjectMeta{
+			Name:      "port-ingress",
+			Namespace: "default",
+		},
+		Spec: &networkingv1.IngressSpec{
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: strPtr(serviceName),
+					Port: port,
+				},
+			},
+		},
+	}
+
+	payload, err := kubewarden_testing.BuildValidationRequest(&ingress, &settings)
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	responsePayload, err := Validate(payload)
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	var response kubewarden_protocol.ValidationResponse
+	if err = json.Unmarshal(responsePayload, &response); err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	return response
+}
+
+func TestApprovalWhenServicePortExists(t *testing.T) {
+	// 数字端口
+	response := validateIngressWithPort(t, "my-service", &networkingv1.ServiceBackendPort{Number: 8080})
+	if !response.Accepted {
+		t.Errorf("Unexpected rejection for existing port number: %s", *response.Message)
+	}
+
+	// 命名端口
+	response = validateIngressWithPort(t, "my-service", &networkingv1.ServiceBackendPort{Name: "metrics"})
+	if !response.Accepted {
+		t.Errorf("Unexpected rejection for existing port name: %s", *response.Message)
+	}
+}
+
+func TestRejectionWhenServicePortNumberMissing(t *testing.T) {
+	response := validateIngressWithPort(t, "my-service", &networkingv1.ServiceBackendPort{Number: 8443})
+	if response.Accepted {
+		t.Fatal("Expected rejection when Service does not expose the port")
+	}
+
+	expectedMessage := "Service 'my-service' in namespace 'default' does not expose port 8443 referenced by defaultBackend " +
+		"(available ports: 80/TCP (http), 8080/TCP (web), 9090/TCP (metrics))"
+	if response.Message == nil {
+		t.Fatalf("expected response to have a message")
+	}
+	if *response.Message != expectedMessage {
+		t.Errorf("Got '%s' instead of '%s'", *response.Message, expectedMessage)
+	}
+}
+
+func TestRejectionWhenServicePortNameMissing(t *testing.T) {
+	response := validateIngressWithPort(t, "my-service", &networkingv1.ServiceBackendPort{Name: "grpc"})
+	if response.Accepted {
+		t.Fatal("Expected rejection when Service does not expose the named port")
+	}
+
+	expectedMessage := "Service 'my-service' in namespace 'default' does not expose port 'grpc' referenced by defaultBackend " +
+		"(available ports: 80/TCP (http), 8080/TCP (web), 9090/TCP (metrics))"
+	if response.Message == nil {
+		t.Fatalf("expected response to have a message")
+	}
+	if *response.Message != expectedMessage {
+		t.Errorf("Got '%s' instead of '%s'", *response.Message, expectedMessage)
+	}
+}
+
+func TestExternalNameServiceSkipsPortCheck(t *testing.T) {
+	response := validateIngressWithPort(t, "external-service", &networkingv1.ServiceBackendPort{Number: 443})
+	if !response.Accepted {
+		t.Errorf("Unexpected rejection for ExternalName Service: %s", *response.Message)
+	}
+}
+
+func TestRejectionListsAllMissingServices(t *testing.T) {
+	setupTestEnv()
+	settings := Settings{
+		EnforceServiceExists: true,
+	}
+	ingress := networkingv1.Ingress{
+		Metadata: &metav1.ObjectMeta{
+			Name:      "many-backends",
+			Namespace: "default",
+		},
+		Spec: &networkingv1.IngressSpec{
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: strPtr("missing-a"),
+					Port: &networkingv1.ServiceBackendPort{Number: 80},
+				},
+			},
+			Rules: []*networkingv1.IngressRule{
+				{
+					Host: "foo.bar.com",
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []*networkingv1.HTTPIngressPath{
+							{
+								Path:     "/a",
+								PathType: strPtr("Prefix"),
+								Backend: &networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: strPtr("missing-a"),
+										Port: &networkingv1.ServiceBackendPort{Number: 80},
+									},
+								},
+							},
+							{
+								Path:     "/b",
+								PathType: strPtr("Prefix"),
+								Backend: &networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: strPtr("missing-b"),
+										Port: &networkingv1.ServiceBackendPort{Number: 80},
+									},
+								},
+							},
+							{
+								Path:     "/c",
+								PathType: strPtr("Prefix"),
+								Backend: &networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: strPtr("unreachable-service"),
+										Port: &networkingv1.ServiceBackendPort{Number: 80},
+									},
+								},
+							},
+							{
+								Path:     "/d",
+								PathType: strPtr("Prefix"),
+								Backend: &networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: strPtr("my-service"),
+										Port: &networkingv1.ServiceBackendPort{Number: 80},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	payload, err := kubewarden_testing.BuildValidationRequest(&ingress, &settings)
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	responsePayload, err := Validate(payload)
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	var response kubewarden_protocol.ValidationResponse
+	if err = json.Unmarshal(responsePayload, &response); err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+
+	if response.Accepted {
+		t.Fatal("Expected rejection when several Services are missing")
+	}
+	expectedMessage := "Service 'missing-a' does not exist in namespace 'default' " +
+		"(referenced by defaultBackend, rules[0].http.paths[0] (host 'foo.bar.com', path '/a')); " +
+		"Service 'missing-b' does not exist in namespace 'default' " +
+		"(referenced by rules[0].http.paths[1] (host 'foo.bar.com', path '/b')). " +
+		"Errors checking referenced objects: Service 'unreachable-service' " +
+		"(referenced by rules[0].http.paths[2] (host 'foo.bar.com', path '/c')): host call failed (transient error): connection refused"
+	if response.Message == nil {
+		t.Fatalf("expected response to have a message")
+	}
+	if *response.Message != expectedMessage {
+		t.Errorf("Got '%s' instead of '%s'", *response.Message, expectedMessage)
+	}
+}
+
+func TestWarnModeAcceptsAndLogsMissingServices(t *testing.T) {
+	setupTestEnv()
+
+	// 临时替换全局 logger 以捕获 warn 日志
+	var buf bytes.Buffer
+	originalLogger := logger
+	logger = onelog.New(&buf, onelog.WARN)
+	defer func() { logger = originalLogger }()
+
+	settings := Settings{
+		EnforcementMode: enforcementModeWarn,
+	}
+	ingress := networkingv1.Ingress{
+		Metadata: &metav1.ObjectMeta{
+			Name:      "warn-ingress",
+			Namespace: "default",
+		},
+		Spec: &networkingv1.IngressSpec{
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: strPtr("non-existent-service"),
+					Port: &networkingv1.ServiceBackendPort{Number: 80},
+				},
+			},
+		},
+	}
+
+	objectRaw, _ := json.Marshal(&ingress)
+	settingsRaw, _ := json.Marshal(&settings)
+	payload, _ := json.Marshal(kubewarden_protocol.ValidationRequest{
+		Request: kubewarden_protocol.KubernetesAdmissionRequest{
+			Uid:    "warn-uid",
+			Object: objectRaw,
+		},
+		Settings: settingsRaw,
+	})
+
+	responsePayload, err := Validate(payload)
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	var response kubewarden_protocol.ValidationResponse
+	if err = json.Unmarshal(responsePayload, &response); err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+
+	if !response.Accepted {
+		t.Errorf("Expected warn mode to accept the request, got: %s", *response.Message)
+	}
+	output := buf.String()
+	for _, expected := range []string{`"uid":"warn-uid"`, `"name":"warn-ingress"`, "non-existent-service"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected warn log to contain %s, got: %s", expected, output)
+		}
+	}
+}
+
+func TestValidateDispatchesByGroupAndKind(t *testing.T) {
+	tests := []struct {
+		name          string
+		group         string
+		kind          string
+		object        interface{}
+		expectMessage string
+	}{
+		{
+			name:          "Ingress with its API group",
+			group:         ingressAPIGroup,
+			kind:          ingressKind,
+			object:        pathIngress("non-existent-service"),
+			expectMessage: "Service 'non-existent-service' does not exist in namespace 'default'",
+		},
+		{
+			name:          "Route from another API group",
+			group:         "example.com",
+			kind:          openShiftRouteKind,
+			object:        openShiftRouteJSON(`{"kind":"Service","name":"my-service"}`, "", ""),
+			expectMessage: "Resource kind 'example.com/Route' is not supported by this policy",
+		},
+		{
+			name:          "unregistered core kind",
+			kind:          "ConfigMap",
+			object:        json.RawMessage(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm","namespace":"default"}}`),
+			expectMessage: "Resource kind 'ConfigMap' is not supported by this policy",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestEnv()
+			settings := Settings{EnforceServiceExists: true}
+			response := validateKindWithClient(t, tt.group, tt.kind, tt.object, &settings)
+			if response.Accepted {
+				t.Fatal("Expected rejection")
+			}
+			if !strings.Contains(*response.Message, tt.expectMessage) {
+				t.Errorf("Expected message to contain '%s', got '%s'", tt.expectMessage, *response.Message)
+			}
+		})
+	}
+}
+
+// validateKindWithClient 以指定的 API 组与 Kind 构造请求，使用当前 host.Client 执行 validate 并解析响应。
+func validateKindWithClient(t *testing.T, group, kind string, object interface{}, settings *Settings) kubewarden_protocol.ValidationResponse {
+	t.Helper()
+	objectRaw, err := json.Marshal(object)
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	settingsRaw, err := json.Marshal(settings)
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	payload, err := json.Marshal(kubewarden_protocol.ValidationRequest{
+		Request: kubewarden_protocol.KubernetesAdmissionRequest{
+			Uid:    "test-uid",
+			Kind:   kubewarden_protocol.GroupVersionKind{Group: group, Kind: kind},
+			Object: objectRaw,
+		},
+		Settings: settingsRaw,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	responsePayload, err := Validate(payload)
+	if err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	var response kubewarden_protocol.ValidationResponse
+	if err = json.Unmarshal(responsePayload, &response); err != nil {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	return response
+}
+
+// strPtr 返回字符串指针的辅助函数。
+func strPtr(s string) *string {
+	return &s
+}